@@ -0,0 +1,262 @@
+package xmuslogger
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type DropPolicy int8
+
+const (
+	DropNewest       DropPolicy = iota // Drop the record being written
+	DropOldest                         // Make room by dropping the oldest queued record
+	BlockWithTimeout                   // Wait for room, then drop the record
+)
+
+// AsyncWriter queues records in a lock-free ring buffer and writes them to
+// the wrapped writer from a background goroutine, so logging never blocks
+// on slow outputs. When the buffer is full records are dropped according
+// to the drop policy.
+type AsyncWriter struct {
+	w            io.Writer
+	policy       DropPolicy
+	timeout      time.Duration
+	pollInterval time.Duration
+	onDrop       func(dropped int)
+
+	ring    *ringBuffer
+	dropped atomic.Int64
+	closed  atomic.Bool
+	space   chan struct{}      // Signalled when the consumer frees slots
+	flushes chan chan struct{} // Drain requests from Flush
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+type AsyncOption func(*AsyncWriter)
+
+// NewAsyncWriter wraps w with a buffer of size records, rounded up to a
+// power of two.
+func NewAsyncWriter(w io.Writer, size int, options ...AsyncOption) *AsyncWriter {
+	a := &AsyncWriter{
+		w:            w,
+		policy:       DropNewest,
+		timeout:      100 * time.Millisecond,
+		pollInterval: 10 * time.Millisecond,
+		ring:         newRingBuffer(size),
+		space:        make(chan struct{}, 1),
+		flushes:      make(chan chan struct{}),
+		done:         make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt(a)
+	}
+
+	a.wg.Add(1)
+	go a.run()
+	return a
+}
+
+func AsyncDropPolicy(policy DropPolicy) AsyncOption {
+	return func(a *AsyncWriter) {
+		a.policy = policy
+	}
+}
+
+// AsyncBlockTimeout sets how long BlockWithTimeout waits for room.
+func AsyncBlockTimeout(timeout time.Duration) AsyncOption {
+	return func(a *AsyncWriter) {
+		a.timeout = timeout
+	}
+}
+
+// AsyncPollInterval sets how often the background goroutine checks for
+// new records when the buffer is empty.
+func AsyncPollInterval(interval time.Duration) AsyncOption {
+	return func(a *AsyncWriter) {
+		a.pollInterval = interval
+	}
+}
+
+// AsyncOnDrop is called from the background goroutine with the number of
+// records dropped since the previous call.
+func AsyncOnDrop(fn func(dropped int)) AsyncOption {
+	return func(a *AsyncWriter) {
+		a.onDrop = fn
+	}
+}
+
+func (a *AsyncWriter) Write(p []byte) (n int, err error) {
+	if a.closed.Load() {
+		return 0, os.ErrClosed
+	}
+
+	data := append([]byte(nil), p...) // p may be reused by the caller
+	if a.ring.push(data) {
+		return len(p), nil
+	}
+
+	switch a.policy {
+	case DropOldest:
+		for !a.ring.push(data) {
+			if _, ok := a.ring.pop(); ok {
+				a.dropped.Add(1)
+			}
+		}
+		return len(p), nil
+	case BlockWithTimeout:
+		deadline := time.Now().Add(a.timeout)
+		for {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				break
+			}
+			if remaining > a.pollInterval {
+				remaining = a.pollInterval
+			}
+			select {
+			case <-a.space:
+			case <-time.After(remaining):
+			}
+			if a.ring.push(data) {
+				return len(p), nil
+			}
+		}
+	}
+
+	a.dropped.Add(1)
+	return len(p), nil
+}
+
+// Flush blocks until every record queued so far has been written.
+func (a *AsyncWriter) Flush() error {
+	reply := make(chan struct{})
+	select {
+	case a.flushes <- reply:
+		<-reply
+	case <-a.done:
+	}
+	return nil
+}
+
+// Close drains the buffer and stops the background goroutine. The wrapped
+// writer is not closed.
+func (a *AsyncWriter) Close() error {
+	a.once.Do(func() {
+		a.closed.Store(true)
+		close(a.done)
+		a.wg.Wait()
+	})
+	return nil
+}
+
+func (a *AsyncWriter) run() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(a.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		a.drain()
+		select {
+		case <-ticker.C:
+		case reply := <-a.flushes:
+			a.drain()
+			close(reply)
+		case <-a.done:
+			a.drain()
+			return
+		}
+	}
+}
+
+func (a *AsyncWriter) drain() {
+	for {
+		data, ok := a.ring.pop()
+		if !ok {
+			break
+		}
+		select {
+		case a.space <- struct{}{}:
+		default:
+		}
+		if _, err := a.w.Write(data); err != nil {
+			handleError(err)
+		}
+	}
+	if n := a.dropped.Swap(0); n > 0 && a.onDrop != nil {
+		a.onDrop(int(n))
+	}
+}
+
+// ringBuffer is a bounded lock-free queue (Vyukov's MPMC design). Producers
+// may also pop to implement DropOldest.
+type ringBuffer struct {
+	mask  uint64
+	slots []ringSlot
+	head  atomic.Uint64 // Next position to push
+	tail  atomic.Uint64 // Next position to pop
+}
+
+type ringSlot struct {
+	seq  atomic.Uint64
+	data []byte
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	n := 2
+	for n < size {
+		n <<= 1
+	}
+	r := &ringBuffer{mask: uint64(n - 1), slots: make([]ringSlot, n)}
+	for i := range r.slots {
+		r.slots[i].seq.Store(uint64(i))
+	}
+	return r
+}
+
+func (r *ringBuffer) push(data []byte) bool {
+	pos := r.head.Load()
+	for {
+		slot := &r.slots[pos&r.mask]
+		seq := slot.seq.Load()
+		switch diff := int64(seq - pos); {
+		case diff == 0:
+			if r.head.CompareAndSwap(pos, pos+1) {
+				slot.data = data
+				slot.seq.Store(pos + 1)
+				return true
+			}
+			pos = r.head.Load()
+		case diff < 0:
+			return false // Full
+		default:
+			pos = r.head.Load()
+		}
+	}
+}
+
+func (r *ringBuffer) pop() ([]byte, bool) {
+	pos := r.tail.Load()
+	for {
+		slot := &r.slots[pos&r.mask]
+		seq := slot.seq.Load()
+		switch diff := int64(seq - (pos + 1)); {
+		case diff == 0:
+			if r.tail.CompareAndSwap(pos, pos+1) {
+				data := slot.data
+				slot.data = nil
+				slot.seq.Store(pos + r.mask + 1)
+				return data, true
+			}
+			pos = r.tail.Load()
+		case diff < 0:
+			return nil, false // Empty
+		default:
+			pos = r.tail.Load()
+		}
+	}
+}
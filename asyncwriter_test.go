@@ -0,0 +1,168 @@
+package xmuslogger
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingWriter holds every write until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+	buf     SafeBuffer
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	<-b.release
+	return b.buf.Write(p)
+}
+
+func TestAsyncWriterFlush(t *testing.T) {
+	var out SafeBuffer
+	w := NewAsyncWriter(&out, 1024, AsyncPollInterval(time.Hour))
+	defer w.Close()
+
+	logger := New().Output(w)
+	for i := 0; i < 100; i++ {
+		logger.Info().Int("i", i).Msg("async")
+	}
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 100 {
+		t.Fatalf("Expected 100 log lines after flush, got %d", len(lines))
+	}
+	for i, line := range lines {
+		entry, err := parseLogLine(line)
+		if err != nil {
+			t.Fatalf("Failed to parse line %d: %v", i, err)
+		}
+		if entry["i"] != float64(i) {
+			t.Errorf("Expected records in order, line %d has i=%v", i, entry["i"])
+		}
+	}
+}
+
+func TestAsyncWriterDropNewest(t *testing.T) {
+	bw := &blockingWriter{release: make(chan struct{})}
+	var dropped atomic.Int64
+	w := NewAsyncWriter(bw, 4, AsyncPollInterval(time.Millisecond), AsyncOnDrop(func(n int) {
+		dropped.Add(int64(n))
+	}))
+
+	w.Write([]byte("first\n"))
+	time.Sleep(20 * time.Millisecond) // Let the consumer pick up and block on "first"
+	for i := 0; i < 10; i++ {
+		w.Write([]byte("queued\n"))
+	}
+	close(bw.release)
+	w.Close()
+
+	if got := strings.Count(bw.buf.String(), "queued"); got != 4 {
+		t.Errorf("Expected 4 queued records to survive, got %d", got)
+	}
+	if dropped.Load() != 6 {
+		t.Errorf("Expected 6 dropped records, got %d", dropped.Load())
+	}
+}
+
+func TestAsyncWriterDropOldest(t *testing.T) {
+	bw := &blockingWriter{release: make(chan struct{})}
+	w := NewAsyncWriter(bw, 4, AsyncDropPolicy(DropOldest), AsyncPollInterval(time.Millisecond))
+
+	w.Write([]byte("first\n"))
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		w.Write([]byte{byte('0' + i), '\n'})
+	}
+	close(bw.release)
+	w.Close()
+
+	if got := bw.buf.String(); got != "first\n6\n7\n8\n9\n" {
+		t.Errorf("Expected the newest records to survive, got %q", got)
+	}
+}
+
+func TestAsyncWriterBlockWithTimeout(t *testing.T) {
+	bw := &blockingWriter{release: make(chan struct{})}
+	w := NewAsyncWriter(bw, 2,
+		AsyncDropPolicy(BlockWithTimeout),
+		AsyncBlockTimeout(20*time.Millisecond),
+		AsyncPollInterval(time.Millisecond),
+	)
+
+	w.Write([]byte("first\n"))
+	time.Sleep(20 * time.Millisecond)
+	w.Write([]byte("a\n"))
+	w.Write([]byte("b\n"))
+
+	start := time.Now()
+	w.Write([]byte("timed out\n"))
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("Expected Write to block until the timeout, returned after %v", elapsed)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(bw.release)
+	}()
+	w.Write([]byte("waited\n")) // Room frees up before the timeout
+	w.Close()
+
+	out := bw.buf.String()
+	if strings.Contains(out, "timed out") || !strings.Contains(out, "waited") {
+		t.Errorf("Unexpected output %q", out)
+	}
+}
+
+func TestAsyncWriterConcurrent(t *testing.T) {
+	var out SafeBuffer
+	w := NewAsyncWriter(&out, 8192)
+	logger := New().Output(w)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				logger.Info().Int("i", i).Msg("concurrent")
+			}
+		}()
+	}
+	wg.Wait()
+	logger.Close()
+	w.Close()
+
+	if _, err := w.Write([]byte("late\n")); err == nil {
+		t.Error("Expected error writing after Close")
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 1000 {
+		t.Errorf("Expected 1000 log lines, got %d", lines)
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	r := newRingBuffer(3) // Rounded up to 4
+	for i := 0; i < 4; i++ {
+		if !r.push([]byte{byte(i)}) {
+			t.Fatalf("push %d failed", i)
+		}
+	}
+	if r.push([]byte{4}) {
+		t.Error("Expected push into a full buffer to fail")
+	}
+	for i := 0; i < 4; i++ {
+		data, ok := r.pop()
+		if !ok || data[0] != byte(i) {
+			t.Fatalf("pop %d = %v, %v", i, data, ok)
+		}
+	}
+	if _, ok := r.pop(); ok {
+		t.Error("Expected pop from an empty buffer to fail")
+	}
+}
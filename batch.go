@@ -0,0 +1,137 @@
+package xmuslogger
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// batcher collects items and sends them in batches, when a batch is full,
+// on every interval and on flush. Batches are sent one at a time, in order.
+type batcher[T any] struct {
+	send     func(batch []T) error
+	size     int
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending []T
+	started bool
+	closed  bool
+	sendMu  sync.Mutex    // Serializes sends
+	kick    chan struct{} // Asks the background goroutine to send
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+func newBatcher[T any](size int, interval time.Duration, send func(batch []T) error) *batcher[T] {
+	if size < 1 {
+		size = 1
+	}
+	return &batcher[T]{
+		send:     send,
+		size:     size,
+		interval: interval,
+		kick:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// add queues item. A full batch is sent before add returns, or in the
+// background when async is set.
+func (b *batcher[T]) add(item T, async bool) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return os.ErrClosed
+	}
+	if !b.started {
+		// Started lazily so writers that are never used cost nothing
+		b.started = true
+		b.wg.Add(1)
+		go b.run()
+	}
+	b.pending = append(b.pending, item)
+	full := len(b.pending) >= b.size
+	b.mu.Unlock()
+
+	if !full {
+		return nil
+	}
+	if !async {
+		return b.flush()
+	}
+	select {
+	case b.kick <- struct{}{}:
+	default: // A send is already pending
+	}
+	return nil
+}
+
+func (b *batcher[T]) flush() error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	return b.send(batch)
+}
+
+// requeue puts items back ahead of the pending ones, to be sent again
+// with the next batch. At most limit items are kept in total, and the
+// number of items dropped is returned.
+func (b *batcher[T]) requeue(items []T, limit int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	keep := limit - len(b.pending)
+	if keep < 0 {
+		keep = 0
+	}
+	dropped := 0
+	if len(items) > keep {
+		dropped = len(items) - keep
+		items = items[:keep]
+	}
+	b.pending = append(append(make([]T, 0, len(items)+len(b.pending)), items...), b.pending...)
+	return dropped
+}
+
+// close stops the background goroutine and sends what is left.
+func (b *batcher[T]) close() error {
+	b.once.Do(func() {
+		b.mu.Lock()
+		b.closed = true
+		b.mu.Unlock()
+		close(b.done)
+		b.wg.Wait()
+	})
+	return b.flush()
+}
+
+func (b *batcher[T]) run() {
+	defer b.wg.Done()
+
+	var tick <-chan time.Time
+	if b.interval > 0 {
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+		case <-b.kick:
+		case <-b.done:
+			return
+		}
+		if err := b.flush(); err != nil {
+			handleError(err)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package xmuslogger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// CBOR major types and the values used by CBOREncoder (RFC 8949).
+const (
+	cborUint     = 0
+	cborNegInt   = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborTag      = 6
+	cborSimple   = 7
+	cborFalse    = 0xf4
+	cborTrue     = 0xf5
+	cborMapStart = 0xbf // Indefinite-length map
+	cborBreak    = 0xff
+
+	cborTagRFC3339   = 0
+	cborTagEpoch     = 1
+	cborTagJSON      = 262 // Embedded JSON, RFC 8949 / IANA registry
+	cborMaxDepth     = 64
+	cborMaxStringLen = 64 << 20
+)
+
+var errCBORMalformed = errors.New("xmuslogger: malformed CBOR")
+
+// CBOREncoder writes each record as a CBOR map (RFC 8949). Records are
+// self-delimiting, so no line break is written between them. Times are
+// encoded as epoch seconds (tag 1), as a float when they have a fractional
+// part, and values passed to Event.Interface as embedded JSON (tag 262).
+// Use CBORToJSON to read the output. Remote writers that need JSON records,
+// such as OTLPWriter, transcode them.
+type CBOREncoder struct{}
+
+func (CBOREncoder) AppendString(dst []byte, key, val string) []byte {
+	dst = appendCBORText(dst, key)
+	return appendCBORText(dst, val)
+}
+
+func (CBOREncoder) AppendInt64(dst []byte, key string, val int64) []byte {
+	dst = appendCBORText(dst, key)
+	return appendCBORInt(dst, val)
+}
+
+func (CBOREncoder) AppendBool(dst []byte, key string, val bool) []byte {
+	dst = appendCBORText(dst, key)
+	if val {
+		return append(dst, cborTrue)
+	}
+	return append(dst, cborFalse)
+}
+
+func (CBOREncoder) AppendTime(dst []byte, key string, val time.Time) []byte {
+	dst = appendCBORText(dst, key)
+	dst = appendCBORHead(dst, cborTag, cborTagEpoch)
+	if val.Nanosecond() == 0 {
+		return appendCBORInt(dst, val.Unix())
+	}
+	return appendCBORFloat(dst, float64(val.UnixNano())/1e9)
+}
+
+func (CBOREncoder) AppendJSON(dst []byte, key string, val []byte) []byte {
+	dst = appendCBORText(dst, key)
+	dst = appendCBORHead(dst, cborTag, cborTagJSON)
+	dst = appendCBORHead(dst, cborBytes, uint64(len(val)))
+	return append(dst, val...)
+}
+
+func (e CBOREncoder) AppendRecord(dst, fields []byte, msg string, t time.Time, level Level) []byte {
+	dst = append(dst, cborMapStart)
+	dst = append(dst, fields...)
+	dst = e.AppendString(dst, "message", msg)
+	dst = e.AppendTime(dst, "time", t)
+	dst = e.AppendString(dst, "level", level.String())
+	return append(dst, cborBreak)
+}
+
+func (CBOREncoder) AppendLineBreak(dst []byte) []byte {
+	return dst
+}
+
+// EncodeRecord converts a JSON record, for use with OutputEncoder.
+func (e CBOREncoder) EncodeRecord(dst, record []byte) ([]byte, error) {
+	return transcodeJSON(dst, record, e)
+}
+
+// fieldValue returns the JSON form of key's value, which is enough to tell
+// values apart for deduplication.
+func (CBOREncoder) fieldValue(fields []byte, key string) []byte {
+	r := bytes.NewReader(fields)
+	d := cborDecoder{r: r}
+	want := appendQuoted(nil, key)
+	var k []byte
+	for r.Len() > 0 {
+		var err error
+		if k, err = d.item(k[:0], 1); err != nil {
+			return nil
+		}
+		val, err := d.item(nil, 1)
+		if err != nil {
+			return nil
+		}
+		if bytes.Equal(k, want) {
+			return val
+		}
+	}
+	return nil
+}
+
+func appendCBORHead(dst []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(dst, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(dst, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return append(dst, major|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(dst, major|27, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32),
+		byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendCBORInt(dst []byte, val int64) []byte {
+	if val < 0 {
+		return appendCBORHead(dst, cborNegInt, uint64(-1-val))
+	}
+	return appendCBORHead(dst, cborUint, uint64(val))
+}
+
+// appendCBORFloat writes f as a double-precision float.
+func appendCBORFloat(dst []byte, f float64) []byte {
+	dst = append(dst, cborSimple<<5|27)
+	return binary.BigEndian.AppendUint64(dst, math.Float64bits(f))
+}
+
+// appendCBORText writes s as a text string, or as a byte string when it is
+// not valid UTF-8.
+func appendCBORText(dst []byte, s string) []byte {
+	major := byte(cborText)
+	if !utf8.ValidString(s) {
+		major = cborBytes
+	}
+	dst = appendCBORHead(dst, major, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// CBORToJSON reads the CBOR records in r, as written by CBOREncoder, and
+// writes each to w as a line of JSON.
+func CBORToJSON(w io.Writer, r io.Reader) error {
+	cr, ok := r.(cborReader)
+	if !ok {
+		cr = bufio.NewReader(r)
+	}
+	d := cborDecoder{r: cr}
+
+	var buf []byte
+	for {
+		var err error
+		buf, err = d.item(buf[:0], 0)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(buf, '\n')); err != nil {
+			return err
+		}
+	}
+}
+
+type cborReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// cborDecoder converts CBOR data items to JSON.
+type cborDecoder struct {
+	r cborReader
+}
+
+// item appends the JSON form of the next data item. It returns io.EOF only
+// when r ends cleanly before the item at depth 0.
+func (d *cborDecoder) item(dst []byte, depth int) ([]byte, error) {
+	major, info, n, err := d.head()
+	if err != nil {
+		if depth > 0 {
+			return dst, unexpectedEOF(err)
+		}
+		return dst, err
+	}
+	if major == cborSimple && info == 31 {
+		return dst, errCBORMalformed // Unexpected break
+	}
+	return d.value(dst, major, info, n, depth)
+}
+
+func (d *cborDecoder) head() (major, info byte, n uint64, err error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info = b>>5, b&0x1f
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info <= 27:
+		var buf [8]byte
+		size := 1 << (info - 24)
+		if _, err := io.ReadFull(d.r, buf[:size]); err != nil {
+			return 0, 0, 0, unexpectedEOF(err)
+		}
+		for _, c := range buf[:size] {
+			n = n<<8 | uint64(c)
+		}
+	case info == 31:
+		if major == cborUint || major == cborNegInt || major == cborTag {
+			return 0, 0, 0, errCBORMalformed
+		}
+	default:
+		return 0, 0, 0, errCBORMalformed
+	}
+	return major, info, n, nil
+}
+
+func (d *cborDecoder) value(dst []byte, major, info byte, n uint64, depth int) ([]byte, error) {
+	if depth > cborMaxDepth {
+		return dst, errCBORMalformed
+	}
+
+	switch major {
+	case cborUint:
+		return strconv.AppendUint(dst, n, 10), nil
+	case cborNegInt:
+		if n == math.MaxUint64 {
+			return append(dst, "-18446744073709551616"...), nil
+		}
+		dst = append(dst, '-')
+		return strconv.AppendUint(dst, n+1, 10), nil
+	case cborBytes:
+		b, err := d.str(cborBytes, info, n)
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, '"')
+		dst = append(dst, base64.RawURLEncoding.EncodeToString(b)...)
+		return append(dst, '"'), nil
+	case cborText:
+		s, err := d.str(cborText, info, n)
+		if err != nil {
+			return dst, err
+		}
+		return appendQuoted(dst, string(s)), nil
+	case cborArray:
+		return d.array(dst, info, n, depth)
+	case cborMap:
+		return d.object(dst, info, n, depth)
+	case cborTag:
+		return d.tag(dst, n, depth)
+	}
+	return d.simple(dst, info, n)
+}
+
+// str reads a definite or chunked indefinite-length string.
+func (d *cborDecoder) str(major, info byte, n uint64) ([]byte, error) {
+	if info != 31 {
+		return d.read(n)
+	}
+	var s []byte
+	for {
+		m, chunkInfo, size, err := d.head()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if m == cborSimple && chunkInfo == 31 {
+			return s, nil
+		}
+		if m != major || chunkInfo == 31 {
+			return nil, errCBORMalformed
+		}
+		chunk, err := d.read(size)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, chunk...)
+	}
+}
+
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > cborMaxStringLen {
+		return nil, errCBORMalformed
+	}
+	// Grow with the data actually read rather than trusting n.
+	b, err := io.ReadAll(io.LimitReader(d.r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(b)) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b, nil
+}
+
+func (d *cborDecoder) array(dst []byte, info byte, n uint64, depth int) ([]byte, error) {
+	dst = append(dst, '[')
+	for i := uint64(0); info == 31 || i < n; i++ {
+		major, itemInfo, size, err := d.head()
+		if err != nil {
+			return dst, unexpectedEOF(err)
+		}
+		if major == cborSimple && itemInfo == 31 {
+			if info != 31 {
+				return dst, errCBORMalformed
+			}
+			break
+		}
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if dst, err = d.value(dst, major, itemInfo, size, depth+1); err != nil {
+			return dst, err
+		}
+	}
+	return append(dst, ']'), nil
+}
+
+func (d *cborDecoder) object(dst []byte, info byte, n uint64, depth int) ([]byte, error) {
+	dst = append(dst, '{')
+	var key []byte
+	for i := uint64(0); info == 31 || i < n; i++ {
+		major, keyInfo, size, err := d.head()
+		if err != nil {
+			return dst, unexpectedEOF(err)
+		}
+		if major == cborSimple && keyInfo == 31 {
+			if info != 31 {
+				return dst, errCBORMalformed
+			}
+			break
+		}
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+
+		// JSON keys must be strings; other keys are quoted as they print.
+		if key, err = d.value(key[:0], major, keyInfo, size, depth+1); err != nil {
+			return dst, err
+		}
+		if major == cborText {
+			dst = append(dst, key...)
+		} else {
+			dst = appendQuoted(dst, string(key))
+		}
+		dst = append(dst, ':')
+
+		if dst, err = d.item(dst, depth+1); err != nil {
+			return dst, err
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+func (d *cborDecoder) tag(dst []byte, tag uint64, depth int) ([]byte, error) {
+	major, info, n, err := d.head()
+	if err != nil {
+		return dst, unexpectedEOF(err)
+	}
+
+	switch {
+	case tag == cborTagEpoch && (major == cborUint || major == cborNegInt):
+		sec := int64(n)
+		if major == cborNegInt {
+			sec = -1 - sec
+		}
+		return appendQuoted(dst, time.Unix(sec, 0).Format(time.RFC3339)), nil
+	case tag == cborTagEpoch && major == cborSimple && info >= 25 && info <= 27:
+		f := cborFloat(info, n)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return append(dst, "null"...), nil
+		}
+		sec, frac := math.Modf(f)
+		t := time.Unix(int64(sec), int64(frac*1e9))
+		return appendQuoted(dst, t.Format(time.RFC3339Nano)), nil
+	case tag == cborTagJSON && major == cborBytes:
+		raw, err := d.str(cborBytes, info, n)
+		if err != nil {
+			return dst, err
+		}
+		return append(dst, raw...), nil
+	}
+	// Other tags, including RFC 3339 strings (tag 0), print as their content.
+	return d.value(dst, major, info, n, depth+1)
+}
+
+func (d *cborDecoder) simple(dst []byte, info byte, n uint64) ([]byte, error) {
+	switch info {
+	case 20:
+		return append(dst, "false"...), nil
+	case 21:
+		return append(dst, "true"...), nil
+	case 25, 26, 27:
+		f := cborFloat(info, n)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return append(dst, "null"...), nil
+		}
+		return strconv.AppendFloat(dst, f, 'g', -1, 64), nil
+	}
+	return append(dst, "null"...), nil // null, undefined and unassigned simple values
+}
+
+func cborFloat(info byte, n uint64) float64 {
+	switch info {
+	case 25:
+		return halfToFloat(uint16(n))
+	case 26:
+		return float64(math.Float32frombits(uint32(n)))
+	}
+	return math.Float64frombits(n)
+}
+
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
+
+func appendQuoted(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	dst = appendEscapedString(dst, s)
+	return append(dst, '"')
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,246 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCBOREncoder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).
+		With().Str("service", "api").Logger().
+		Encoder(CBOREncoder{})
+
+	logger.Info().
+		Str("user", "john").
+		Int("port", 8080).
+		Int64("offset", -5).
+		Bool("tls", true).
+		Interface("tags", []string{"a", "b"}).
+		Msg("Server starting")
+	logger.Error().Str("line", "one\ntwo").Msg("second")
+
+	if buf.Bytes()[buf.Len()-1] != cborBreak {
+		t.Error("Expected no line break after CBOR records")
+	}
+
+	var out bytes.Buffer
+	if err := CBORToJSON(&out, &buf); err != nil {
+		t.Fatalf("CBORToJSON() returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 records, got %q", out.String())
+	}
+
+	record, err := parseLogLine(lines[0])
+	if err != nil {
+		t.Fatalf("Decoded record is not JSON: %v", err)
+	}
+	expected := map[string]interface{}{
+		"service": "api",
+		"user":    "john",
+		"port":    float64(8080),
+		"offset":  float64(-5),
+		"tls":     true,
+		"message": "Server starting",
+		"level":   "info",
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v, record[k])
+		}
+	}
+	if tags, ok := record["tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Errorf("Expected embedded JSON tags, got %v", record["tags"])
+	}
+	if _, err := time.Parse(time.RFC3339, record["time"].(string)); err != nil {
+		t.Errorf("Expected RFC3339 time, got %v", record["time"])
+	}
+
+	second, _ := parseLogLine(lines[1])
+	if second["line"] != "one\ntwo" {
+		t.Errorf("Expected multi-line string to round-trip, got %q", second["line"])
+	}
+}
+
+func TestCBORSmallerThanJSON(t *testing.T) {
+	var jsonBuf, cborBuf bytes.Buffer
+	log := func(l *Logger) {
+		l.Info().Str("request_id", "abc123").Int("status", 200).Bool("cached", false).Msg("request")
+	}
+	log(NewWithOutput(&jsonBuf))
+	log(NewWithOutput(&cborBuf).Encoder(CBOREncoder{}))
+
+	if cborBuf.Len() >= jsonBuf.Len() {
+		t.Errorf("Expected CBOR (%d bytes) to be smaller than JSON (%d bytes)", cborBuf.Len(), jsonBuf.Len())
+	}
+}
+
+func TestCBORRemoteWriter(t *testing.T) {
+	remote := &mockRemoteWriter{}
+	logger := NewWithOutput(io.Discard).Encoder(CBOREncoder{}).Remote(remote)
+	logger.Info().Msg("remote")
+
+	if len(remote.writes) != 1 {
+		t.Fatalf("Expected 1 remote record, got %d", len(remote.writes))
+	}
+	var out bytes.Buffer
+	if err := CBORToJSON(&out, bytes.NewReader(remote.writes[0])); err != nil {
+		t.Fatalf("CBORToJSON() returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"message":"remote"`) {
+		t.Errorf("Expected decoded remote record, got %q", out.String())
+	}
+}
+
+func TestCBORDedupFields(t *testing.T) {
+	var buf SafeBuffer
+	logger := New().Output(&buf).Encoder(CBOREncoder{}).Dedup(NewDeduper(time.Hour, 10, "host"))
+
+	logger.Error().Str("host", "db1").Msg("down")
+	logger.Error().Str("host", "db1").Msg("down")
+	logger.Error().Str("host", "db2").Msg("down")
+	logger.Flush()
+
+	var out bytes.Buffer
+	if err := CBORToJSON(&out, strings.NewReader(buf.String())); err != nil {
+		t.Fatalf("CBORToJSON() returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], `"repeat_count":1`) {
+		t.Errorf("Expected 2 records and a summary, got %q", out.String())
+	}
+}
+
+func TestCBORToJSONValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"uint64", []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, "18446744073709551615"},
+		{"negative", []byte{0x38, 0x63}, "-100"},
+		{"float16", []byte{0xf9, 0x3e, 0x00}, "1.5"},
+		{"float64", []byte{0xfb, 0x3f, 0xf1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}, "1.1"},
+		{"null", []byte{0xf6}, "null"},
+		{"bytes", []byte{0x43, 0x01, 0x02, 0x03}, `"AQID"`},
+		{"indefinite text", []byte{0x7f, 0x62, 'a', 'b', 0x61, 'c', 0xff}, `"abc"`},
+		{"indefinite array", []byte{0x9f, 0x01, 0x82, 0x02, 0x03, 0xff}, "[1,[2,3]]"},
+		{"integer key", []byte{0xa1, 0x01, 0x61, 'x'}, `{"1":"x"}`},
+		{"escaped text", []byte{0x62, '"', '\n'}, `"\"\n"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := CBORToJSON(&out, bytes.NewReader(tt.input)); err != nil {
+				t.Fatalf("CBORToJSON() returned error: %v", err)
+			}
+			got := strings.TrimSuffix(out.String(), "\n")
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("Output %s is not valid JSON", got)
+			}
+		})
+	}
+}
+
+func TestCBORToJSONMalformed(t *testing.T) {
+	inputs := map[string][]byte{
+		"truncated map":    {0xbf, 0x61, 'a'},
+		"truncated string": {0x65, 'a', 'b'},
+		"stray break":      {0xff},
+		"reserved info":    {0x1c},
+		"too deep":         bytes.Repeat([]byte{0x81}, cborMaxDepth+2),
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			err := CBORToJSON(io.Discard, bytes.NewReader(input))
+			if err == nil {
+				t.Fatal("Expected error for malformed input")
+			}
+			if !errors.Is(err, errCBORMalformed) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func BenchmarkCBORLogging(b *testing.B) {
+	logger := New().Output(io.Discard).Encoder(CBOREncoder{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info().
+			Str("key1", "value1").
+			Int("key2", 42).
+			Bool("key3", true).
+			Msg("benchmark test")
+	}
+}
+
+func TestCBORTimeFraction(t *testing.T) {
+	want := time.Unix(1700000000, 123456000)
+	b := CBOREncoder{}.AppendTime(nil, "t", want)
+
+	d := cborDecoder{r: bytes.NewReader(b)}
+	d.item(nil, 1) // Key
+	raw, err := d.item(nil, 1)
+	if err != nil {
+		t.Fatalf("Decoding time failed: %v", err)
+	}
+	var s string
+	json.Unmarshal(raw, &s)
+	got, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		t.Fatalf("Expected RFC3339 time, got %s", raw)
+	}
+	if d := got.Sub(want); d < -time.Microsecond || d > time.Microsecond {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestCBORJSONRemoteWriters(t *testing.T) {
+	collector, otlpSrv := newOTLPCollector(t)
+	lokiServer, lokiSrv := newLokiServer(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := serveSyslog(t, l)
+
+	multi := NewMultiRemoteWriter(
+		NewOTLPWriter(otlpSrv.URL),
+		NewLokiRemoteWriter(lokiSrv.URL, LokiLabels("service.name")),
+		NewSyslogRemoteWriter("tcp", l.Addr().String(), testSyslogOptions(SyslogStructuredData("fields@32473"))...),
+	)
+	logger := NewWithOutput(io.Discard).Encoder(CBOREncoder{}).Remote(multi).
+		With().Str("service.name", "checkout").Logger()
+	logger.Warn().Str("user", "john").Msg("slow")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	resourceLogs := collector.requests[0]["resourceLogs"].([]interface{})[0].(map[string]interface{})
+	resAttrs := resourceLogs["resource"].(map[string]interface{})["attributes"].([]interface{})
+	if len(resAttrs) != 1 || resAttrs[0].(map[string]interface{})["key"] != "service.name" {
+		t.Errorf("Expected CBOR context as OTLP resource attributes, got %v", resAttrs)
+	}
+
+	stream := lokiServer.pushes[0].Streams[0]
+	if stream.Stream["service_name"] != "checkout" || !strings.Contains(stream.Values[0][1], `"message":"slow"`) {
+		t.Errorf("Expected a JSON Loki entry, got %+v", stream)
+	}
+
+	if msg := receive(t, frames); !strings.HasPrefix(msg, "<12>1 ") || !strings.Contains(msg, `[fields@32473 service.name="checkout" user="john"] slow`) {
+		t.Errorf("Expected syslog level and structured data, got %q", msg)
+	}
+}
@@ -0,0 +1,58 @@
+package xmuslogger
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor compresses rotated log files and remote payloads.
+type Compressor interface {
+	Extension() string
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+// GzipCompressor compresses with gzip. A zero Level uses the default.
+type GzipCompressor struct {
+	Level int
+}
+
+func (c GzipCompressor) Extension() string { return ".gz" }
+
+func (c GzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if c.Level == 0 {
+		return gzip.NewWriter(w), nil
+	}
+	return gzip.NewWriterLevel(w, c.Level)
+}
+
+// ZlibCompressor compresses with zlib. A zero Level uses the default.
+type ZlibCompressor struct {
+	Level int
+}
+
+func (c ZlibCompressor) Extension() string { return ".zz" }
+
+func (c ZlibCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if c.Level == 0 {
+		return zlib.NewWriter(w), nil
+	}
+	return zlib.NewWriterLevel(w, c.Level)
+}
+
+// ZstdCompressor compresses with zstd. Level is a zstd level from 1 to 22;
+// zero uses the default.
+type ZstdCompressor struct {
+	Level int
+}
+
+func (c ZstdCompressor) Extension() string { return ".zst" }
+
+func (c ZstdCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if c.Level == 0 {
+		return zstd.NewWriter(w)
+	}
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.Level)))
+}
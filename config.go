@@ -1,6 +1,9 @@
 package xmuslogger
 
-import "io"
+import (
+	"errors"
+	"io"
+)
 
 func (l *Logger) Level(level Level) *Logger {
 	newLogger := l.clone()
@@ -21,10 +24,109 @@ func (l *Logger) Remote(w RemoteWriter) *Logger {
 	return newLogger
 }
 
+// AddRemote adds w to the logger's remote writers, each with its own queue
+// as in MultiRemoteWriter. A remote writer set with Remote is kept and from
+// then on written in the background too.
+func (l *Logger) AddRemote(w RemoteWriter, options ...RemoteOption) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// A new MultiRemoteWriter, so clones sharing the old one are unaffected.
+	// The remotes it shares stay open until both have been closed.
+	var multi *MultiRemoteWriter
+	switch existing := l.remoteWriter.(type) {
+	case nil:
+		multi = &MultiRemoteWriter{}
+	case *MultiRemoteWriter:
+		multi = existing.share()
+	default:
+		multi = &MultiRemoteWriter{}
+		multi.Add(existing)
+	}
+	multi.Add(w, options...)
+	l.remoteWriter = multi
+}
+
+// RemoteStats returns the counters of each remote writer added with
+// AddRemote, or nil when the remote writer is not a MultiRemoteWriter.
+func (l *Logger) RemoteStats() []RemoteStats {
+	l.mu.RLock()
+	multi, ok := l.remoteWriter.(*MultiRemoteWriter)
+	l.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return multi.Stats()
+}
+
+// RemoteHTTP posts records to endpoint with an HTTPRemoteWriter, framed for
+// the logger's encoder, so set Encoder first.
 func (l *Logger) RemoteHTTP(endpoint string, options ...HTTPOption) *Logger {
+	options = append([]HTTPOption{WithHTTPEncoder(l.encoder)}, options...)
 	return l.Remote(NewHTTPRemoteWriter(endpoint, options...))
 }
 
+func (l *Logger) Hook(h Hook) *Logger {
+	newLogger := l.clone()
+	newLogger.hooks = append(newLogger.hooks, h)
+	return newLogger
+}
+
+// Redact masks sensitive fields and values. Context fields are masked when
+// they are added, so set it before calling With.
+func (l *Logger) Redact(r *Redactor) *Logger {
+	newLogger := l.clone()
+	newLogger.redactor = r
+	return newLogger
+}
+
+func (l *Logger) Sample(s Sampler, options ...SampleOption) *Logger {
+	newLogger := l.clone()
+	newLogger.sampling = &sampling{sampler: s}
+	for _, opt := range options {
+		opt(newLogger.sampling)
+	}
+	return newLogger
+}
+
+func (l *Logger) Dedup(d *Deduper) *Logger {
+	newLogger := l.clone()
+	newLogger.dedup = d
+	return newLogger
+}
+
+// Encoder sets the record format, e.g. LogfmtEncoder{}. Context fields
+// added earlier are re-serialized with enc.
+func (l *Logger) Encoder(enc Encoder) *Logger {
+	newLogger := l.clone()
+	newLogger.encoder = enc
+	newLogger.context = newLogger.context[:0]
+	for _, f := range newLogger.contextFields {
+		newLogger.context = f(enc, newLogger.context)
+	}
+	return newLogger
+}
+
+// Synchronized guarantees that each record is written to every output in
+// a single, uninterrupted Write, wrapping current and future outputs with
+// SyncWriter.
+func (l *Logger) Synchronized() *Logger {
+	newLogger := l.clone()
+	newLogger.syncWrites = true
+	for i, w := range newLogger.writers {
+		newLogger.writers[i] = SyncWriter(w)
+	}
+	return newLogger
+}
+
+// Caller adds a "caller" field with the file:line of the call to Trace,
+// Debug, Info, Warn or Error.
+func (l *Logger) Caller() *Logger {
+	newLogger := l.clone()
+	newLogger.caller = true
+	return newLogger
+}
+
 // ✅ FIXED: Clone the logger before creating context
 func (l *Logger) With() *Context {
 	clonedLogger := l.clone() // Create a copy first
@@ -35,19 +137,107 @@ func (l *Logger) SetOutput(w io.Writer) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.Logger.SetOutput(&loggerWriter{parent: l})
-	l.writers = []io.Writer{w}
+	l.writers = []io.Writer{l.wrapWriter(w)}
 }
 
-func (l *Logger) Flush() error {
-	if l.remoteWriter != nil {
-		return l.remoteWriter.Flush()
+// AddOutput adds w to the logger's outputs. The writers slice is never
+// modified in place, so events already in flight keep a consistent view.
+func (l *Logger) AddOutput(w io.Writer, options ...OutputOption) {
+	w = l.wrapWriter(w)
+	if len(options) > 0 {
+		o := &output{w: w, min: TraceLevel}
+		for _, opt := range options {
+			opt(o)
+		}
+		w = o
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	writers := make([]io.Writer, len(l.writers), len(l.writers)+1)
+	copy(writers, l.writers)
+	l.writers = append(writers, w)
+}
+
+// RemoveOutput removes every output added as w.
+func (l *Logger) RemoveOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	writers := make([]io.Writer, 0, len(l.writers))
+	for _, existing := range l.writers {
+		if unwrapOutput(existing) != w {
+			writers = append(writers, existing)
+		}
+	}
+	l.writers = writers
+}
+
+func (l *Logger) wrapWriter(w io.Writer) io.Writer {
+	if l.syncWrites {
+		return SyncWriter(w)
 	}
-	return nil
+	return w
+}
+
+// Outputs returns the writers the logger currently writes to.
+func (l *Logger) Outputs() []io.Writer {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	outputs := make([]io.Writer, len(l.writers))
+	for i, w := range l.writers {
+		outputs[i] = unwrapOutput(w)
+	}
+	return outputs
+}
+
+// flusher is implemented by outputs that buffer records, such as AsyncWriter.
+type flusher interface {
+	Flush() error
+}
+
+func (l *Logger) Flush() error {
+	return l.finish(RemoteWriter.Flush)
 }
 
+// Close flushes local outputs without closing them and closes the remote writer.
 func (l *Logger) Close() error {
-	if l.remoteWriter != nil {
-		return l.remoteWriter.Close()
+	return l.finish(RemoteWriter.Close)
+}
+
+// finish flushes the local outputs and passes the remote writer to fn,
+// returning both errors.
+func (l *Logger) finish(fn func(RemoteWriter) error) error {
+	if l.dedup != nil {
+		l.dedup.Flush()
+	}
+	err := l.flushWriters()
+	l.mu.RLock()
+	remoteWriter := l.remoteWriter
+	l.mu.RUnlock()
+	if remoteWriter == nil {
+		return err
+	}
+	if rerr := fn(remoteWriter); rerr != nil {
+		if err == nil {
+			return rerr
+		}
+		return errors.Join(err, rerr)
+	}
+	return err
+}
+
+func (l *Logger) flushWriters() error {
+	l.mu.RLock()
+	writers := l.writers
+	l.mu.RUnlock()
+
+	var err error
+	for _, w := range writers {
+		if f, ok := w.(flusher); ok {
+			if ferr := f.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
 	}
-	return nil
+	return err
 }
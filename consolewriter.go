@@ -0,0 +1,339 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parts of a console line that can be ordered, excluded or formatted.
+const (
+	ConsolePartTime       = "time"
+	ConsolePartLevel      = "level"
+	ConsolePartMessage    = "message"
+	ConsolePartFieldName  = "field_name"
+	ConsolePartFieldValue = "field_value"
+)
+
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+// ConsoleFormatter renders a single part of a console line.
+type ConsoleFormatter func(value interface{}) string
+
+// ConsoleWriter renders the JSON records written by a Logger as
+// human-friendly lines:
+//
+//	19:04:05 INF Server starting port=8080 service=api
+//
+// Nested objects and multi-line strings such as stack traces are printed
+// indented below the line. Anything that is not a JSON object is passed
+// through unchanged.
+type ConsoleWriter struct {
+	out           io.Writer
+	color         bool
+	timeFormat    string
+	partsOrder    []string
+	partsExclude  map[string]bool
+	fieldsOrder   []string
+	fieldsExclude map[string]bool
+	formatters    map[string]ConsoleFormatter
+}
+
+type ConsoleOption func(*ConsoleWriter)
+
+// NewConsoleWriter writes to out. Colors are enabled only when out is a
+// terminal and the NO_COLOR environment variable is not set.
+func NewConsoleWriter(out io.Writer, options ...ConsoleOption) *ConsoleWriter {
+	c := &ConsoleWriter{
+		out:           out,
+		color:         isTerminal(out) && os.Getenv("NO_COLOR") == "",
+		timeFormat:    "15:04:05",
+		partsOrder:    []string{ConsolePartTime, ConsolePartLevel, ConsolePartMessage},
+		partsExclude:  make(map[string]bool),
+		fieldsExclude: make(map[string]bool),
+		formatters:    make(map[string]ConsoleFormatter),
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// ConsoleColor forces colors on or off.
+func ConsoleColor(enabled bool) ConsoleOption {
+	return func(c *ConsoleWriter) {
+		c.color = enabled
+	}
+}
+
+func ConsoleTimeFormat(layout string) ConsoleOption {
+	return func(c *ConsoleWriter) {
+		c.timeFormat = layout
+	}
+}
+
+// ConsolePartsOrder sets the order of the time, level and message parts.
+func ConsolePartsOrder(parts ...string) ConsoleOption {
+	return func(c *ConsoleWriter) {
+		c.partsOrder = parts
+	}
+}
+
+func ConsolePartsExclude(parts ...string) ConsoleOption {
+	return func(c *ConsoleWriter) {
+		for _, p := range parts {
+			c.partsExclude[p] = true
+		}
+	}
+}
+
+// ConsoleFieldsOrder prints the given fields first, in order; the remaining
+// fields follow sorted by name.
+func ConsoleFieldsOrder(keys ...string) ConsoleOption {
+	return func(c *ConsoleWriter) {
+		c.fieldsOrder = keys
+	}
+}
+
+func ConsoleFieldsExclude(keys ...string) ConsoleOption {
+	return func(c *ConsoleWriter) {
+		for _, k := range keys {
+			c.fieldsExclude[k] = true
+		}
+	}
+}
+
+// ConsoleFormat replaces the default formatting, including colors, of one
+// of the ConsolePart* parts.
+func ConsoleFormat(part string, f ConsoleFormatter) ConsoleOption {
+	return func(c *ConsoleWriter) {
+		c.formatters[part] = f
+	}
+}
+
+func (c *ConsoleWriter) Write(p []byte) (n int, err error) {
+	var record map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	if err := d.Decode(&record); err != nil {
+		return c.out.Write(p)
+	}
+
+	var line, blocks bytes.Buffer
+	for _, part := range c.partsOrder {
+		if c.partsExclude[part] {
+			continue
+		}
+		if s := c.formatPart(part, record[part]); s != "" {
+			if line.Len() > 0 {
+				line.WriteByte(' ')
+			}
+			line.WriteString(s)
+		}
+	}
+
+	for _, key := range c.fieldKeys(record) {
+		val := record[key]
+		if block := c.block(val); block != "" {
+			blocks.WriteString("    ")
+			blocks.WriteString(c.formatFieldName(key))
+			blocks.WriteByte('\n')
+			blocks.WriteString(block)
+			continue
+		}
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(c.formatFieldName(key))
+		line.WriteString(c.formatFieldValue(key, val))
+	}
+
+	line.WriteByte('\n')
+	line.Write(blocks.Bytes())
+	if _, err := c.out.Write(line.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// fieldKeys returns the keys printed as fields, in output order.
+func (c *ConsoleWriter) fieldKeys(record map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	for _, part := range c.partsOrder {
+		seen[part] = true
+	}
+
+	var keys []string
+	for _, k := range c.fieldsOrder {
+		if _, ok := record[k]; ok && !seen[k] && !c.fieldsExclude[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+
+	var rest []string
+	for k := range record {
+		if !seen[k] && !c.fieldsExclude[k] && !c.partsExclude[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+func (c *ConsoleWriter) formatPart(part string, val interface{}) string {
+	if f, ok := c.formatters[part]; ok {
+		return f(val)
+	}
+	if val == nil {
+		return ""
+	}
+
+	switch part {
+	case ConsolePartTime:
+		s := toString(val)
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			s = t.Format(c.timeFormat)
+		}
+		return c.colorize(s, colorGray)
+	case ConsolePartLevel:
+		return c.formatLevel(toString(val))
+	default:
+		return toString(val)
+	}
+}
+
+func (c *ConsoleWriter) formatLevel(level string) string {
+	switch level {
+	case "trace":
+		return c.colorize("TRC", colorMagenta)
+	case "debug":
+		return c.colorize("DBG", colorYellow)
+	case "info":
+		return c.colorize("INF", colorGreen)
+	case "warn":
+		return c.colorize("WRN", colorRed)
+	case "error":
+		return c.colorize("ERR", colorBoldRed)
+	case "fatal":
+		return c.colorize("FTL", colorBoldRed)
+	default:
+		return strings.ToUpper(level)
+	}
+}
+
+func (c *ConsoleWriter) formatFieldName(key string) string {
+	if f, ok := c.formatters[ConsolePartFieldName]; ok {
+		return f(key)
+	}
+	if key == "error" {
+		return c.colorize(key+"=", colorRed)
+	}
+	return c.colorize(key+"=", colorCyan)
+}
+
+func (c *ConsoleWriter) formatFieldValue(key string, val interface{}) string {
+	if f, ok := c.formatters[ConsolePartFieldValue]; ok {
+		return f(val)
+	}
+	s := toString(val)
+	if strings.ContainsAny(s, " \t\"=") {
+		s = strconv.Quote(s)
+	}
+	if key == "error" {
+		return c.colorize(s, colorRed)
+	}
+	return s
+}
+
+// block renders nested objects and multi-line strings, such as stack
+// traces, indented on their own lines. It returns "" for inline values.
+func (c *ConsoleWriter) block(val interface{}) string {
+	var text string
+	switch v := val.(type) {
+	case map[string]interface{}, []interface{}:
+		if isEmptyJSON(v) {
+			return ""
+		}
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return ""
+		}
+		text = string(b)
+	case string:
+		if !strings.Contains(v, "\n") {
+			return ""
+		}
+		text = strings.TrimRight(v, "\n")
+	default:
+		return ""
+	}
+
+	var b strings.Builder
+	for _, l := range strings.Split(text, "\n") {
+		b.WriteString("        ")
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (c *ConsoleWriter) colorize(s, color string) string {
+	if !c.color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+}
+
+func isEmptyJSON(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// isTerminal reports whether w is a character device such as a TTY.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,122 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConsoleWriterFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(NewConsoleWriter(&buf))
+
+	logger.Info().Str("service", "api").Int("port", 8080).Str("path", "/a b").Msg("Server starting")
+
+	line := strings.TrimSpace(buf.String())
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) != 3 {
+		t.Fatalf("Unexpected console line %q", line)
+	}
+	if len(parts[0]) != len("15:04:05") {
+		t.Errorf("Expected short time first, got %q", parts[0])
+	}
+	if parts[1] != "INF" {
+		t.Errorf("Expected INF level, got %q", parts[1])
+	}
+	if parts[2] != `Server starting path="/a b" port=8080 service=api` {
+		t.Errorf("Unexpected message and fields %q", parts[2])
+	}
+	if strings.Contains(line, "\x1b[") {
+		t.Error("Colors should be disabled for non-terminal output")
+	}
+}
+
+func TestConsoleWriterColor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(NewConsoleWriter(&buf, ConsoleColor(true)))
+
+	logger.Error().Err(errors.New("boom")).Msg("failed")
+
+	output := buf.String()
+	if !strings.Contains(output, colorBoldRed+"ERR"+colorReset) {
+		t.Errorf("Expected colored level, got %q", output)
+	}
+	if !strings.Contains(output, colorRed+"boom"+colorReset) {
+		t.Errorf("Expected colored error value, got %q", output)
+	}
+}
+
+func TestConsoleWriterNoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if NewConsoleWriter(&bytes.Buffer{}).color {
+		t.Error("NO_COLOR should disable colors")
+	}
+}
+
+func TestConsoleWriterOrderingAndExclusion(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(NewConsoleWriter(&buf,
+		ConsolePartsOrder(ConsolePartLevel, ConsolePartMessage),
+		ConsolePartsExclude(ConsolePartTime),
+		ConsoleFieldsOrder("request_id"),
+		ConsoleFieldsExclude("secret"),
+	))
+
+	logger.Warn().Str("b", "2").Str("a", "1").Str("request_id", "r-1").Str("secret", "x").Msg("ordered")
+
+	if got := strings.TrimSpace(buf.String()); got != "WRN ordered request_id=r-1 a=1 b=2" {
+		t.Errorf("Unexpected console line %q", got)
+	}
+}
+
+func TestConsoleWriterCustomFormatters(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(NewConsoleWriter(&buf,
+		ConsolePartsExclude(ConsolePartTime),
+		ConsoleFormat(ConsolePartLevel, func(v interface{}) string { return fmt.Sprintf("[%s]", v) }),
+		ConsoleFormat(ConsolePartMessage, func(v interface{}) string { return fmt.Sprintf("%q", v) }),
+		ConsoleFormat(ConsolePartFieldName, func(v interface{}) string { return fmt.Sprintf("%s:", v) }),
+		ConsoleFormat(ConsolePartFieldValue, func(v interface{}) string { return fmt.Sprintf("<%v>", v) }),
+	))
+
+	logger.Info().Int("n", 1).Msg("custom")
+
+	if got := strings.TrimSpace(buf.String()); got != `[info] "custom" n:<1>` {
+		t.Errorf("Unexpected console line %q", got)
+	}
+}
+
+func TestConsoleWriterBlocks(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(NewConsoleWriter(&buf, ConsolePartsExclude(ConsolePartTime)))
+
+	logger.Error().
+		Interface("request", map[string]interface{}{"method": "GET", "path": "/"}).
+		Str("stack", "main.go:10\nserver.go:42").
+		Msg("panic")
+
+	expected := "ERR panic\n" +
+		"    request=\n" +
+		"        {\n" +
+		"          \"method\": \"GET\",\n" +
+		"          \"path\": \"/\"\n" +
+		"        }\n" +
+		"    stack=\n" +
+		"        main.go:10\n" +
+		"        server.go:42\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestConsoleWriterPassThrough(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewConsoleWriter(&buf)
+
+	w.Write([]byte("not json\n"))
+	if buf.String() != "not json\n" {
+		t.Errorf("Expected non-JSON input to pass through, got %q", buf.String())
+	}
+}
@@ -1,23 +1,44 @@
 package xmuslogger
 
+import "strconv"
+
 type Context struct {
 	logger *Logger
 }
 
 func (c *Context) Str(key, val string) *Context {
-	c.logger.context = appendString(c.logger.context, key, val)
-	return c
+	val = c.logger.redactor.field(key, val)
+	return c.add(func(enc Encoder, dst []byte) []byte {
+		return enc.AppendString(dst, key, val)
+	})
 }
 
 func (c *Context) Int(key string, val int) *Context {
-	c.logger.context = appendInt(c.logger.context, key, val)
-	return c
+	if c.logger.redactor.matchKey(key) {
+		return c.Str(key, strconv.Itoa(val))
+	}
+	return c.add(func(enc Encoder, dst []byte) []byte {
+		return enc.AppendInt64(dst, key, int64(val))
+	})
 }
 
 func (c *Context) Bool(key string, val bool) *Context {
-	c.logger.context = appendBool(c.logger.context, key, val)
+	if c.logger.redactor.matchKey(key) {
+		return c.Str(key, strconv.FormatBool(val))
+	}
+	return c.add(func(enc Encoder, dst []byte) []byte {
+		return enc.AppendBool(dst, key, val)
+	})
+}
+
+// add serializes f with the logger's encoder and keeps it so the context
+// can be rebuilt if the encoder changes.
+func (c *Context) add(f contextField) *Context {
+	c.logger.context = f(c.logger.encoder, c.logger.context)
+	c.logger.contextFields = append(c.logger.contextFields, f)
 	return c
 }
+
 func (c *Context) Logger() *Logger {
 	return c.logger
 }
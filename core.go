@@ -1,5 +1,10 @@
 package xmuslogger
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Level int8
 
 const (
@@ -11,6 +16,18 @@ const (
 	FatalLevel
 )
 
+var levelNames = [...]string{"trace", "debug", "info", "warn", "error", "fatal"}
+
 func (l Level) String() string {
-	return [...]string{"trace", "debug", "info", "warn", "error", "fatal"}[l]
+	return levelNames[l]
+}
+
+// ParseLevel returns the level named s, ignoring case.
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("xmuslogger: unknown level %q", s)
 }
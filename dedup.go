@@ -0,0 +1,245 @@
+package xmuslogger
+
+import (
+	"container/list"
+	"io"
+	"sync"
+	"time"
+)
+
+// Deduper suppresses repeated events. Events with the same level, message
+// and selected field values seen within the window are dropped, and once
+// the window closes a single summary record carrying repeat_count,
+// first_seen and last_seen is written in their place.
+type Deduper struct {
+	window  time.Duration
+	size    int
+	fields  []string
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type dedupEntry struct {
+	key          string
+	level        Level
+	msg          string
+	buf          []byte // Fields of the first occurrence
+	count        int
+	first, last  time.Time
+	writers      []io.Writer
+	remoteWriter RemoteWriter
+	async        bool
+	stdlib       bool
+	enc          Encoder
+	contextLen   int
+	timer        *time.Timer
+}
+
+// NewDeduper tracks at most size distinct keys, evicting the least recently
+// seen one when full. fields selects which field values take part in the key.
+func NewDeduper(window time.Duration, size int, fields ...string) *Deduper {
+	if size <= 0 {
+		size = 1000
+	}
+	return &Deduper{
+		window:  window,
+		size:    size,
+		fields:  fields,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// admit reports whether e should be written or counted as a repeat.
+func (d *Deduper) admit(e *Event, msg string) bool {
+	key := d.key(e, msg)
+	now := time.Now()
+
+	d.mu.Lock()
+	if el, ok := d.entries[key]; ok {
+		entry := el.Value.(*dedupEntry)
+		entry.count++
+		entry.last = now
+		d.lru.MoveToFront(el)
+		d.mu.Unlock()
+		return false
+	}
+
+	entry := &dedupEntry{
+		key:          key,
+		level:        e.level,
+		msg:          msg,
+		buf:          append([]byte(nil), e.buf...),
+		first:        now,
+		last:         now,
+		writers:      e.writers,
+		remoteWriter: e.remoteWriter,
+		async:        e.async,
+		stdlib:       e.stdlib,
+		enc:          e.enc,
+		contextLen:   e.contextLen,
+	}
+	d.entries[key] = d.lru.PushFront(entry)
+	entry.timer = time.AfterFunc(d.window, func() { d.expire(entry) })
+
+	var evicted *dedupEntry
+	if d.lru.Len() > d.size {
+		evicted = d.remove(d.lru.Back())
+	}
+	d.mu.Unlock()
+
+	if evicted != nil {
+		evicted.emit()
+	}
+	return true
+}
+
+func (d *Deduper) key(e *Event, msg string) string {
+	key := make([]byte, 0, len(msg)+16)
+	key = append(key, byte(e.level))
+	if e.stdlib {
+		// Kept apart, as summaries are marked with the source of the first record
+		key = append(key, 's')
+	}
+	key = append(key, msg...)
+	scanner, ok := e.enc.(fieldScanner)
+	if !ok && len(d.fields) > 0 {
+		// Without a scanner the whole field set takes part in the key
+		key = append(key, 0)
+		return string(append(key, e.buf...))
+	}
+	for _, f := range d.fields {
+		key = append(key, 0)
+		key = append(key, scanner.fieldValue(e.buf, f)...)
+	}
+	return string(key)
+}
+
+func (d *Deduper) expire(entry *dedupEntry) {
+	d.mu.Lock()
+	el, ok := d.entries[entry.key]
+	if !ok || el.Value != entry {
+		d.mu.Unlock()
+		return
+	}
+	d.remove(el)
+	d.mu.Unlock()
+
+	entry.emit()
+}
+
+// remove drops el from the cache; d.mu must be held.
+func (d *Deduper) remove(el *list.Element) *dedupEntry {
+	entry := d.lru.Remove(el).(*dedupEntry)
+	delete(d.entries, entry.key)
+	entry.timer.Stop()
+	return entry
+}
+
+// Flush writes summaries for all pending repeats and resets the cache.
+func (d *Deduper) Flush() {
+	d.mu.Lock()
+	pending := make([]*dedupEntry, 0, d.lru.Len())
+	for d.lru.Len() > 0 {
+		pending = append(pending, d.remove(d.lru.Back()))
+	}
+	d.mu.Unlock()
+
+	for _, entry := range pending {
+		entry.emit()
+	}
+}
+
+// emit writes the summary record if any repeats were suppressed.
+func (entry *dedupEntry) emit() {
+	if entry.count == 0 {
+		return
+	}
+
+	e := getEvent()
+	e.level = entry.level
+	e.writers = entry.writers
+	e.remoteWriter = entry.remoteWriter
+	e.async = entry.async
+	e.hooks = nil
+	e.redactor = nil
+	e.dedup = nil
+	e.stdlib = entry.stdlib
+	e.enc = entry.enc
+	e.contextLen = entry.contextLen
+	e.done = putEvent
+
+	e.buf = append(e.buf, entry.buf...)
+	e.buf = e.enc.AppendInt64(e.buf, "repeat_count", int64(entry.count))
+	e.buf = e.enc.AppendTime(e.buf, "first_seen", entry.first)
+	e.buf = e.enc.AppendTime(e.buf, "last_seen", entry.last)
+	e.Msg(entry.msg)
+}
+
+// fieldScanner is implemented by encoders whose fields can be looked up by
+// key, so that NewDeduper's fields work with them.
+type fieldScanner interface {
+	fieldValue(fields []byte, key string) []byte
+}
+
+// fieldValue returns the serialized value of key in buf, or nil.
+func fieldValue(buf []byte, key string) []byte {
+	for i := 0; i < len(buf); {
+		k, v, next := nextField(buf, i)
+		if next <= i {
+			return nil
+		}
+		if string(k) == key {
+			return v
+		}
+		i = next
+	}
+	return nil
+}
+
+// nextField scans one `"key":value,` pair starting at i.
+func nextField(buf []byte, i int) (key, val []byte, next int) {
+	if buf[i] != '"' {
+		return nil, nil, i
+	}
+	end := skipString(buf, i)
+	key = buf[i+1 : end-1]
+	if end >= len(buf) || buf[end] != ':' {
+		return nil, nil, i
+	}
+
+	start := end + 1
+	pos := start
+	depth := 0
+	for pos < len(buf) {
+		switch buf[pos] {
+		case '"':
+			pos = skipString(buf, pos)
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				return key, buf[start:pos], pos + 1
+			}
+		}
+		pos++
+	}
+	return key, buf[start:pos], pos
+}
+
+// skipString returns the index just past the JSON string starting at i.
+func skipString(buf []byte, i int) int {
+	for j := i + 1; j < len(buf); j++ {
+		switch buf[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(buf)
+}
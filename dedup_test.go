@@ -0,0 +1,155 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupSuppressesRepeats(t *testing.T) {
+	var buf SafeBuffer
+	logger := New().Output(&buf).Dedup(NewDeduper(time.Hour, 10))
+
+	for i := 0; i < 5; i++ {
+		logger.Error().Msg("connection refused")
+	}
+	logger.Error().Msg("other error")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines before flush, got %d", len(lines))
+	}
+
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 log lines after flush, got %d", len(lines))
+	}
+	summary, err := parseLogLine(lines[2])
+	if err != nil {
+		t.Fatalf("Failed to parse summary: %v", err)
+	}
+	if summary["message"] != "connection refused" {
+		t.Errorf("Expected summary for 'connection refused', got %v", summary["message"])
+	}
+	if summary["repeat_count"] != float64(4) {
+		t.Errorf("Expected repeat_count=4, got %v", summary["repeat_count"])
+	}
+	if summary["level"] != "error" {
+		t.Errorf("Expected level=error, got %v", summary["level"])
+	}
+	if _, ok := summary["first_seen"]; !ok {
+		t.Error("Expected first_seen field")
+	}
+	if _, ok := summary["last_seen"]; !ok {
+		t.Error("Expected last_seen field")
+	}
+}
+
+func TestDedupStdlibSummary(t *testing.T) {
+	var buf SafeBuffer
+	logger := New().Output(&buf).Dedup(NewDeduper(time.Hour, 10))
+
+	for i := 0; i < 3; i++ {
+		logger.Printf("retrying %s", "db")
+	}
+	logger.Info().Msg("retrying db")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 log lines, got %d: %q", len(lines), lines)
+	}
+	summary, err := parseLogLine(lines[2])
+	if err != nil {
+		t.Fatalf("Failed to parse summary: %v", err)
+	}
+	if summary["source"] != "stdlib" || summary["repeat_count"] != float64(2) {
+		t.Errorf("Expected a stdlib summary of 2 repeats, got %v", summary)
+	}
+}
+
+func TestDedupSelectedFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(&buf).Dedup(NewDeduper(time.Hour, 10, "host"))
+
+	logger.Error().Str("host", "a").Int("attempt", 1).Msg("down")
+	logger.Error().Str("host", "a").Int("attempt", 2).Msg("down")
+	logger.Error().Str("host", "b").Int("attempt", 1).Msg("down")
+	logger.Warn().Str("host", "a").Msg("down")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Errorf("Expected 3 distinct log lines, got %d", len(lines))
+	}
+}
+
+func TestDedupWindowExpiry(t *testing.T) {
+	var buf SafeBuffer
+	logger := New().Output(&buf).Dedup(NewDeduper(20*time.Millisecond, 10))
+
+	logger.Info().Msg("tick")
+	logger.Info().Msg("tick")
+	logger.Info().Msg("once")
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "repeat_count") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !strings.Contains(buf.String(), `"repeat_count":1`) {
+		t.Fatalf("Expected summary after window, got %s", buf.String())
+	}
+
+	logger.Info().Msg("tick")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Errorf("Expected a fresh record after the window, got %d lines", len(lines))
+	}
+	if strings.Count(buf.String(), "repeat_count") != 1 {
+		t.Error("Events without repeats should not produce a summary")
+	}
+}
+
+func TestDedupLRUEviction(t *testing.T) {
+	var buf SafeBuffer
+	logger := New().Output(&buf).Dedup(NewDeduper(time.Hour, 2))
+
+	logger.Info().Msg("a")
+	logger.Info().Msg("a")
+	logger.Info().Msg("b")
+	logger.Info().Msg("c") // Evicts "a" and emits its summary
+
+	if !strings.Contains(buf.String(), `"repeat_count":1`) {
+		t.Errorf("Expected evicted entry to emit its summary, got %s", buf.String())
+	}
+
+	logger.Close()
+	if strings.Count(buf.String(), "repeat_count") != 1 {
+		t.Errorf("Expected exactly one summary, got %s", buf.String())
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	buf := []byte(`"a":"x,\"y\"","obj":{"k":[1,2]},"n":42,`)
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"a", `"x,\"y\""`},
+		{"obj", `{"k":[1,2]}`},
+		{"n", "42"},
+		{"missing", ""},
+	}
+	for _, tt := range tests {
+		if got := string(fieldValue(buf, tt.key)); got != tt.expected {
+			t.Errorf("fieldValue(%q) = %q, expected %q", tt.key, got, tt.expected)
+		}
+	}
+}
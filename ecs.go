@@ -0,0 +1,245 @@
+package xmuslogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ecsVersion = "8.11.0"
+
+const ecsTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// ECSEncoder writes records following the Elastic Common Schema:
+//
+//	{"@timestamp":"...","log":{"level":"info"},"message":"...","ecs":{"version":"8.11.0"}}
+//
+// Errors are written as error.message, error.type and, for errors that
+// print a stack with %+v, error.stack_trace. A "caller" field of the form
+// file:line, as added by Logger.Caller, becomes log.origin.file.*, and the stdlib source field becomes
+// log.logger. Dotted keys are expanded into nested objects.
+type ECSEncoder struct{}
+
+func (ECSEncoder) AppendString(dst []byte, key, val string) []byte {
+	return appendString(dst, key, val)
+}
+
+func (ECSEncoder) AppendInt64(dst []byte, key string, val int64) []byte {
+	return appendInt64(dst, key, val)
+}
+
+func (ECSEncoder) AppendBool(dst []byte, key string, val bool) []byte {
+	return appendBool(dst, key, val)
+}
+
+func (ECSEncoder) AppendTime(dst []byte, key string, val time.Time) []byte {
+	return appendTime(dst, key, val)
+}
+
+func (ECSEncoder) AppendJSON(dst []byte, key string, val []byte) []byte {
+	return appendRaw(dst, key, val)
+}
+
+func (ECSEncoder) AppendRecord(dst, fields []byte, msg string, t time.Time, level Level) []byte {
+	var root ecsNode
+	root.set("@timestamp", ecsReserved(appendQuoted(nil, t.UTC().Format(ecsTimeFormat))))
+	root.set("log.level", ecsReserved(appendQuoted(nil, level.String())))
+	root.set("message", ecsReserved(appendQuoted(nil, msg)))
+	root.set("ecs.version", ecsReserved(appendQuoted(nil, ecsVersion)))
+
+	for i := 0; i < len(fields); {
+		k, v, next := nextField(fields, i)
+		if next <= i {
+			break
+		}
+		i = next
+
+		switch key := string(k); key {
+		case "error":
+			root.set("error.message", v)
+		case "source":
+			if string(v) == `"stdlib"` {
+				root.set("log.logger", v)
+			} else {
+				root.set(key, v)
+			}
+		case "caller":
+			var caller string
+			if json.Unmarshal(v, &caller) != nil {
+				root.set(key, v)
+				break
+			}
+			file, line := splitCaller(caller)
+			root.set("log.origin.file.name", appendQuoted(nil, file))
+			if line != "" {
+				root.set("log.origin.file.line", []byte(line))
+			}
+		default:
+			root.set(key, v)
+		}
+	}
+	return root.appendTo(dst)
+}
+
+func (ECSEncoder) AppendLineBreak(dst []byte) []byte {
+	return append(dst, '\n')
+}
+
+// EncodeRecord converts a JSON record, for use with OutputEncoder.
+func (e ECSEncoder) EncodeRecord(dst, record []byte) ([]byte, error) {
+	return transcodeJSON(dst, record, e)
+}
+
+func (ECSEncoder) fieldValue(fields []byte, key string) []byte {
+	return fieldValue(fields, key)
+}
+
+func (ECSEncoder) appendError(dst []byte, err error, redact func(string) string) []byte {
+	dst = appendString(dst, "error.message", redact(err.Error()))
+	dst = appendString(dst, "error.type", fmt.Sprintf("%T", err))
+	if _, ok := err.(fmt.Formatter); ok {
+		if stack := fmt.Sprintf("%+v", err); stack != err.Error() {
+			dst = appendString(dst, "error.stack_trace", redact(stack))
+		}
+	}
+	return dst
+}
+
+// errorEncoder is implemented by encoders that write more than the error
+// message for Event.Err.
+type errorEncoder interface {
+	appendError(dst []byte, err error, redact func(string) string) []byte
+}
+
+// ecsNode is a JSON object under construction, keeping keys in insertion
+// order. Values are encoded JSON, ecsReserved or nested nodes.
+type ecsNode struct {
+	keys []string
+	vals []interface{}
+}
+
+// ecsReserved is an encoded value written by the encoder itself, such as
+// log.level. Fields cannot replace it.
+type ecsReserved []byte
+
+// set stores val, a []byte or ecsReserved, under the dotted path key. When
+// part of the path is already a plain value, the rest of the key is kept
+// as-is at that level.
+func (n *ecsNode) set(key string, val interface{}) {
+	for {
+		dot := strings.IndexByte(key, '.')
+		if dot <= 0 || dot == len(key)-1 {
+			n.put(key, val)
+			return
+		}
+
+		i := n.index(key[:dot])
+		if i < 0 {
+			child := &ecsNode{}
+			n.keys = append(n.keys, key[:dot])
+			n.vals = append(n.vals, child)
+			n, key = child, key[dot+1:]
+			continue
+		}
+		child, ok := n.vals[i].(*ecsNode)
+		if !ok {
+			n.put(key, val)
+			return
+		}
+		n, key = child, key[dot+1:]
+	}
+}
+
+// put stores val under key at this level. A plain value replacing an
+// object flattens the object into dotted keys beside it, as when the plain
+// value comes first; reserved values are never replaced, nor are objects
+// holding them.
+func (n *ecsNode) put(key string, val interface{}) {
+	i := n.index(key)
+	if i < 0 {
+		n.keys = append(n.keys, key)
+		n.vals = append(n.vals, val)
+		return
+	}
+	switch existing := n.vals[i].(type) {
+	case ecsReserved:
+		return
+	case *ecsNode:
+		if existing.reserved() {
+			return
+		}
+		n.vals[i] = val
+		existing.flatten(key, n)
+		return
+	}
+	n.vals[i] = val
+}
+
+// reserved reports whether n holds a reserved value at any depth.
+func (n *ecsNode) reserved() bool {
+	for _, v := range n.vals {
+		switch v := v.(type) {
+		case ecsReserved:
+			return true
+		case *ecsNode:
+			if v.reserved() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flatten adds n's values to dst under dotted keys starting with prefix.
+func (n *ecsNode) flatten(prefix string, dst *ecsNode) {
+	for i, k := range n.keys {
+		if child, ok := n.vals[i].(*ecsNode); ok {
+			child.flatten(prefix+"."+k, dst)
+			continue
+		}
+		dst.put(prefix+"."+k, n.vals[i])
+	}
+}
+
+func (n *ecsNode) index(key string) int {
+	for i, k := range n.keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendTo writes n as JSON. Keys are stored already escaped.
+func (n *ecsNode) appendTo(dst []byte) []byte {
+	dst = append(dst, '{')
+	for i, k := range n.keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, '"')
+		dst = append(dst, k...)
+		dst = append(dst, '"', ':')
+		switch v := n.vals[i].(type) {
+		case *ecsNode:
+			dst = v.appendTo(dst)
+		case []byte:
+			dst = append(dst, v...)
+		case ecsReserved:
+			dst = append(dst, v...)
+		}
+	}
+	return append(dst, '}')
+}
+
+// splitCaller splits a "file:line" caller; line is empty if there is none.
+func splitCaller(caller string) (file, line string) {
+	if i := strings.LastIndexByte(caller, ':'); i > 0 {
+		if _, err := strconv.Atoi(caller[i+1:]); err == nil {
+			return caller[:i], caller[i+1:]
+		}
+	}
+	return caller, ""
+}
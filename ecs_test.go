@@ -0,0 +1,192 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stackError struct{ msg string }
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) Format(s fmt.State, verb rune) {
+	fmt.Fprint(s, e.msg)
+	if s.Flag('+') {
+		fmt.Fprint(s, "\nmain.run\n\tmain.go:12")
+	}
+}
+
+func decodeECS(t *testing.T, line string) map[string]interface{} {
+	t.Helper()
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", line, err)
+	}
+	return record
+}
+
+func TestECSEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).
+		With().Str("service.name", "api").Logger().
+		Encoder(ECSEncoder{})
+
+	logger.Error().
+		Str("http.request.method", "GET").
+		Int("http.response.status_code", 500).
+		Str("caller", "server/handler.go:42").
+		Err(errors.New("boom")).
+		Msg("request failed")
+
+	line := buf.String()
+	if !strings.HasPrefix(line, `{"@timestamp":"`) {
+		t.Errorf("Expected @timestamp first, got %q", line)
+	}
+	record := decodeECS(t, line)
+
+	if _, err := time.Parse(time.RFC3339Nano, record["@timestamp"].(string)); err != nil {
+		t.Errorf("Invalid @timestamp: %v", record["@timestamp"])
+	}
+	if record["message"] != "request failed" {
+		t.Errorf("Unexpected message: %v", record["message"])
+	}
+
+	log := record["log"].(map[string]interface{})
+	if log["level"] != "error" {
+		t.Errorf("Expected log.level=error, got %v", log["level"])
+	}
+	file := log["origin"].(map[string]interface{})["file"].(map[string]interface{})
+	if file["name"] != "server/handler.go" || file["line"] != float64(42) {
+		t.Errorf("Unexpected log.origin.file: %v", file)
+	}
+	if record["ecs"].(map[string]interface{})["version"] != ecsVersion {
+		t.Errorf("Unexpected ecs: %v", record["ecs"])
+	}
+	if record["service"].(map[string]interface{})["name"] != "api" {
+		t.Errorf("Expected context field to be nested, got %v", record["service"])
+	}
+
+	http := record["http"].(map[string]interface{})
+	if http["request"].(map[string]interface{})["method"] != "GET" ||
+		http["response"].(map[string]interface{})["status_code"] != float64(500) {
+		t.Errorf("Unexpected http object: %v", http)
+	}
+
+	errObj := record["error"].(map[string]interface{})
+	if errObj["message"] != "boom" || errObj["type"] != "*errors.errorString" {
+		t.Errorf("Unexpected error object: %v", errObj)
+	}
+	if _, ok := errObj["stack_trace"]; ok {
+		t.Errorf("Expected no stack trace for plain errors, got %v", errObj)
+	}
+}
+
+func TestECSEncoderStackTrace(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).Encoder(ECSEncoder{})
+	logger.Error().Err(&stackError{msg: "failed"}).Msg("")
+
+	errObj := decodeECS(t, buf.String())["error"].(map[string]interface{})
+	if errObj["type"] != "*xmuslogger.stackError" {
+		t.Errorf("Unexpected error.type: %v", errObj["type"])
+	}
+	if !strings.Contains(errObj["stack_trace"].(string), "main.go:12") {
+		t.Errorf("Expected stack trace, got %v", errObj["stack_trace"])
+	}
+}
+
+func TestECSEncoderStdlib(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).Encoder(ECSEncoder{})
+	logger.SetFlags(0)
+	logger.Print("legacy")
+
+	record := decodeECS(t, buf.String())
+	log := record["log"].(map[string]interface{})
+	if log["logger"] != "stdlib" || log["level"] != "info" {
+		t.Errorf("Expected stdlib record to map to log.logger, got %v", record)
+	}
+	if _, ok := record["source"]; ok {
+		t.Errorf("Expected no source field, got %v", record["source"])
+	}
+}
+
+func TestECSEncoderCaller(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).Encoder(ECSEncoder{}).Caller()
+	logger.Info().Msg("here")
+
+	file := decodeECS(t, buf.String())["log"].(map[string]interface{})["origin"].(map[string]interface{})["file"].(map[string]interface{})
+	if name, _ := file["name"].(string); !strings.HasSuffix(name, "ecs_test.go") {
+		t.Errorf("Expected log.origin.file.name to be this file, got %v", file["name"])
+	}
+	if line, _ := file["line"].(float64); line <= 0 {
+		t.Errorf("Expected log.origin.file.line, got %v", file["line"])
+	}
+}
+
+func TestECSEncoderDottedKeyConflict(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).Encoder(ECSEncoder{})
+	logger.Info().Str("user", "john").Str("user.id", "42").Msg("")
+
+	record := decodeECS(t, buf.String())
+	if record["user"] != "john" || record["user.id"] != "42" {
+		t.Errorf("Expected conflicting dotted key to be kept as-is, got %v", record)
+	}
+}
+
+func TestECSEncoderPlainAfterDottedKey(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).Encoder(ECSEncoder{})
+	logger.Info().Str("a.b", "1").Str("a", "2").Msg("")
+
+	record := decodeECS(t, buf.String())
+	if record["a"] != "2" || record["a.b"] != "1" {
+		t.Errorf("Expected both keys to be kept, got %v", record)
+	}
+}
+
+func TestECSEncoderReservedFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).Encoder(ECSEncoder{})
+	logger.Warn().
+		Str("log.level", "x").
+		Str("message", "fake").
+		Str("@timestamp", "never").
+		Str("ecs", "x").
+		Str("log.logger", "db").
+		Msg("real")
+
+	record := decodeECS(t, buf.String())
+	log := record["log"].(map[string]interface{})
+	if log["level"] != "warn" || log["logger"] != "db" {
+		t.Errorf("Expected log.level to be kept beside log.logger, got %v", log)
+	}
+	if record["message"] != "real" || record["@timestamp"] == "never" {
+		t.Errorf("Expected reserved fields to be kept, got %v", record)
+	}
+	if record["ecs"].(map[string]interface{})["version"] != ecsVersion {
+		t.Errorf("Expected ecs.version to be kept, got %v", record)
+	}
+}
+
+func TestECSOutputEncoder(t *testing.T) {
+	var plain, ecs bytes.Buffer
+	logger := NewWithOutput(&plain)
+	logger.AddOutput(&ecs, OutputEncoder(ECSEncoder{}))
+	logger.Warn().Err(errors.New("disk full")).Msg("alert")
+
+	record := decodeECS(t, ecs.String())
+	if record["error"].(map[string]interface{})["message"] != "disk full" {
+		t.Errorf("Expected error.message from JSON record, got %v", record)
+	}
+	if record["log"].(map[string]interface{})["level"] != "warn" {
+		t.Errorf("Expected log.level from JSON record, got %v", record)
+	}
+}
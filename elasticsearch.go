@@ -0,0 +1,205 @@
+package xmuslogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ElasticsearchRemoteWriter is a RemoteWriter that indexes records through
+// the Elasticsearch or OpenSearch _bulk API. Records are sent as they were
+// serialized, one document each, so they must be JSON encoded. Items the
+// cluster rejects with a retryable status are retried on their own.
+type ElasticsearchRemoteWriter struct {
+	http        *HTTPRemoteWriter
+	url         string
+	index       string
+	httpOptions []HTTPOption
+	maxRetries  int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	batch       *batcher[bulkItem]
+}
+
+type bulkItem struct {
+	action []byte // Action line, including the newline
+	doc    []byte
+}
+
+type ElasticsearchOption func(*ElasticsearchRemoteWriter)
+
+// NewElasticsearchRemoteWriter indexes into the cluster at endpoint, e.g.
+// https://localhost:9200; /_bulk is appended unless present.
+func NewElasticsearchRemoteWriter(endpoint string, options ...ElasticsearchOption) *ElasticsearchRemoteWriter {
+	w := &ElasticsearchRemoteWriter{
+		url:        strings.TrimSuffix(endpoint, "/"),
+		index:      "logs-{2006.01.02}",
+		maxRetries: 5,
+		minBackoff: 500 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+	if !strings.HasSuffix(w.url, "/_bulk") {
+		w.url += "/_bulk"
+	}
+
+	w.http = NewHTTPRemoteWriter(endpoint, w.httpOptions...)
+	w.batch = newBatcher(w.http.batchSize, w.http.flushInterval, w.send)
+	return w
+}
+
+// ElasticsearchIndex sets the index name template. Text in braces is a Go
+// time layout applied to the UTC write time, so the default
+// "logs-{2006.01.02}" gives daily indices such as logs-2026.10.16.
+func ElasticsearchIndex(pattern string) ElasticsearchOption {
+	return func(w *ElasticsearchRemoteWriter) {
+		w.index = pattern
+	}
+}
+
+// ElasticsearchRetry retries requests and items rejected with 429 or a 5xx
+// status up to retries times, backing off exponentially between min and max.
+func ElasticsearchRetry(retries int, min, max time.Duration) ElasticsearchOption {
+	return func(w *ElasticsearchRemoteWriter) {
+		w.maxRetries = retries
+		w.minBackoff = min
+		w.maxBackoff = max
+	}
+}
+
+// ElasticsearchHTTP configures the HTTP transport, e.g. WithHTTPBasicAuth,
+// WithHTTPAPIKey or WithHTTPBatch.
+func ElasticsearchHTTP(options ...HTTPOption) ElasticsearchOption {
+	return func(w *ElasticsearchRemoteWriter) {
+		w.httpOptions = append(w.httpOptions, options...)
+	}
+}
+
+func (w *ElasticsearchRemoteWriter) Write(data []byte) error {
+	return w.write(data, false)
+}
+
+func (w *ElasticsearchRemoteWriter) WriteAsync(data []byte) error {
+	return w.write(data, true)
+}
+
+func (w *ElasticsearchRemoteWriter) Flush() error { return w.batch.flush() }
+func (w *ElasticsearchRemoteWriter) Close() error { return w.batch.close() }
+
+func (w *ElasticsearchRemoteWriter) write(data []byte, async bool) error {
+	data, err := jsonRecord(data)
+	if err != nil {
+		return err
+	}
+	action := []byte(`{"index":{"_index":`)
+	action = appendQuoted(action, indexName(w.index, time.Now().UTC()))
+	action = append(action, "}}\n"...)
+	return w.batch.add(bulkItem{action: action, doc: append([]byte(nil), data...)}, async)
+}
+
+// indexName expands the time layouts in braces.
+func indexName(pattern string, t time.Time) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(pattern, '{')
+		end := strings.IndexByte(pattern, '}')
+		if start < 0 || end < start {
+			b.WriteString(pattern)
+			return b.String()
+		}
+		b.WriteString(pattern[:start])
+		b.WriteString(t.Format(pattern[start+1 : end]))
+		pattern = pattern[end+1:]
+	}
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+func (w *ElasticsearchRemoteWriter) send(items []bulkItem) error {
+	var failed []string // Reasons for items that will not be retried
+	total := len(items)
+
+	err := retryBackoff(w.maxRetries, w.minBackoff, w.maxBackoff, func() (bool, string, error) {
+		retry, reasons, retryAfter, err := w.bulk(items)
+		if err != nil {
+			return false, "", err
+		}
+		failed = append(failed, reasons...)
+		items = retry
+		return len(retry) > 0, retryAfter, nil
+	})
+	if err != nil {
+		return err
+	}
+	for range items {
+		failed = append(failed, "retries exhausted")
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("xmuslogger: %d of %d bulk items failed: %s", len(failed), total, failed[0])
+	}
+	return nil
+}
+
+// bulk sends one _bulk request and returns the items to retry and the
+// reasons for items rejected permanently.
+func (w *ElasticsearchRemoteWriter) bulk(items []bulkItem) (retry []bulkItem, failed []string, retryAfter string, err error) {
+	var body []byte
+	for _, item := range items {
+		body = append(body, item.action...)
+		body = append(body, item.doc...)
+		body = append(body, '\n')
+	}
+
+	resp, err := w.http.post(w.url, "application/x-ndjson", body)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer drainBody(resp)
+	retryAfter = resp.Header.Get("Retry-After")
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5 {
+		return items, nil, retryAfter, nil
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, nil, "", fmt.Errorf("xmuslogger: bulk request failed: %s", resp.Status)
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil, "", fmt.Errorf("xmuslogger: invalid bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil, nil, "", nil
+	}
+
+	// Items are reported in request order, keyed by action
+	for i, res := range result.Items {
+		if i >= len(items) {
+			break
+		}
+		for _, r := range res {
+			switch {
+			case r.Status/100 == 2:
+			case r.Status == http.StatusTooManyRequests || r.Status/100 == 5:
+				retry = append(retry, items[i])
+			default:
+				failed = append(failed, r.Error.Type+": "+r.Error.Reason)
+			}
+		}
+	}
+	return retry, failed, retryAfter, nil
+}
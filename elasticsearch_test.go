@@ -0,0 +1,151 @@
+package xmuslogger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type bulkServer struct {
+	mu       sync.Mutex
+	requests [][]string // Documents per request
+	indices  []string
+	auth     []string
+	reject   func(request int, doc string) (status int, errType string)
+}
+
+func newBulkServer(t *testing.T) (*bulkServer, *httptest.Server) {
+	s := &bulkServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("Unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		var docs []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action struct {
+				Index struct {
+					Index string `json:"_index"`
+				} `json:"index"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || action.Index.Index == "" {
+				t.Errorf("Invalid action line %q", scanner.Text())
+			}
+			s.indices = append(s.indices, action.Index.Index)
+			scanner.Scan()
+			if !json.Valid(scanner.Bytes()) {
+				t.Errorf("Invalid document %q", scanner.Text())
+			}
+			docs = append(docs, scanner.Text())
+		}
+		request := len(s.requests)
+		s.requests = append(s.requests, docs)
+		s.auth = append(s.auth, r.Header.Get("Authorization"))
+
+		var items []string
+		hasErrors := false
+		for _, doc := range docs {
+			status, errType := 201, ""
+			if s.reject != nil {
+				status, errType = s.reject(request, doc)
+			}
+			if status != 201 {
+				hasErrors = true
+				items = append(items, fmt.Sprintf(`{"index":{"status":%d,"error":{"type":%q,"reason":"rejected"}}}`, status, errType))
+			} else {
+				items = append(items, `{"index":{"status":201}}`)
+			}
+		}
+		fmt.Fprintf(w, `{"took":1,"errors":%t,"items":[%s]}`, hasErrors, strings.Join(items, ","))
+	}))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func TestElasticsearchRemoteWriter(t *testing.T) {
+	server, srv := newBulkServer(t)
+	w := NewElasticsearchRemoteWriter(srv.URL,
+		ElasticsearchIndex("app-{2006.01}"),
+		ElasticsearchHTTP(WithHTTPBasicAuth("elastic", "secret"), WithHTTPBatch(10, time.Hour)),
+	)
+
+	logger := NewWithOutput(io.Discard).Remote(w)
+	logger.Info().Str("user", "john").Msg("login")
+	logger.Warn().Msg("slow")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	if len(server.requests) != 1 || len(server.requests[0]) != 2 {
+		t.Fatalf("Expected 1 bulk request with 2 documents, got %v", server.requests)
+	}
+	if !strings.HasPrefix(server.requests[0][0], `{"user":"john","message":"login"`) {
+		t.Errorf("Expected the serialized record as document, got %s", server.requests[0][0])
+	}
+	if want := "app-" + time.Now().UTC().Format("2006.01"); server.indices[0] != want {
+		t.Errorf("Expected index %s, got %s", want, server.indices[0])
+	}
+	if server.auth[0] != "Basic ZWxhc3RpYzpzZWNyZXQ=" {
+		t.Errorf("Unexpected Authorization header %q", server.auth[0])
+	}
+}
+
+func TestElasticsearchRemoteWriterRetriesFailedItems(t *testing.T) {
+	server, srv := newBulkServer(t)
+	server.reject = func(request int, doc string) (int, string) {
+		switch {
+		case request == 0 && strings.Contains(doc, `"busy"`):
+			return 429, "es_rejected_execution_exception"
+		case strings.Contains(doc, `"bad"`):
+			return 400, "mapper_parsing_exception"
+		}
+		return 201, ""
+	}
+	w := NewElasticsearchRemoteWriter(srv.URL,
+		ElasticsearchRetry(3, time.Millisecond, 10*time.Millisecond),
+		ElasticsearchHTTP(WithHTTPAPIKey("a2V5"), WithHTTPBatch(10, time.Hour)),
+	)
+
+	for _, msg := range []string{"ok", "busy", "bad"} {
+		w.Write([]byte(`{"message":"` + msg + `"}`))
+	}
+	err := w.Flush()
+	if err == nil || !strings.Contains(err.Error(), "1 of 3") || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("Expected the permanent failure to be reported, got %v", err)
+	}
+
+	if len(server.requests) != 2 {
+		t.Fatalf("Expected 2 bulk requests, got %d", len(server.requests))
+	}
+	if len(server.requests[1]) != 1 || !strings.Contains(server.requests[1][0], `"busy"`) {
+		t.Errorf("Expected only the rejected item to be retried, got %v", server.requests[1])
+	}
+	if server.auth[0] != "ApiKey a2V5" {
+		t.Errorf("Unexpected Authorization header %q", server.auth[0])
+	}
+}
+
+func TestIndexName(t *testing.T) {
+	ts := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	tests := map[string]string{
+		"logs-{2006.01.02}":       "logs-2026.10.16",
+		"logs":                    "logs",
+		"{2006}-app-{01}":         "2026-app-10",
+		"unterminated-{2006.01.0": "unterminated-{2006.01.0",
+	}
+	for pattern, want := range tests {
+		if got := indexName(pattern, ts); got != want {
+			t.Errorf("indexName(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
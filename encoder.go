@@ -0,0 +1,141 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Encoder serializes fields and records. Event and Context append every
+// field through the logger's encoder, so switching encoders changes the
+// output format without any parsing or re-encoding.
+type Encoder interface {
+	AppendString(dst []byte, key, val string) []byte
+	AppendInt64(dst []byte, key string, val int64) []byte
+	AppendBool(dst []byte, key string, val bool) []byte
+	AppendTime(dst []byte, key string, val time.Time) []byte
+	// AppendJSON appends a value that is already encoded as JSON.
+	AppendJSON(dst []byte, key string, val []byte) []byte
+	// AppendRecord appends a complete record made of the fields encoded so
+	// far and the message, time and level every record carries.
+	AppendRecord(dst, fields []byte, msg string, t time.Time, level Level) []byte
+	// AppendLineBreak terminates a record written to a local output.
+	AppendLineBreak(dst []byte) []byte
+}
+
+// JSONEncoder is the default encoder.
+type JSONEncoder struct{}
+
+func (JSONEncoder) AppendString(dst []byte, key, val string) []byte {
+	return appendString(dst, key, val)
+}
+
+func (JSONEncoder) AppendInt64(dst []byte, key string, val int64) []byte {
+	return appendInt64(dst, key, val)
+}
+
+func (JSONEncoder) AppendBool(dst []byte, key string, val bool) []byte {
+	return appendBool(dst, key, val)
+}
+
+func (JSONEncoder) AppendTime(dst []byte, key string, val time.Time) []byte {
+	return appendTime(dst, key, val)
+}
+
+func (JSONEncoder) AppendJSON(dst []byte, key string, val []byte) []byte {
+	return appendRaw(dst, key, val)
+}
+
+func (JSONEncoder) AppendRecord(dst, fields []byte, msg string, t time.Time, level Level) []byte {
+	dst = append(dst, '{')
+	dst = append(dst, fields...)
+	dst = appendString(dst, "message", msg)
+	dst = appendTime(dst, "time", t)
+	dst = appendString(dst, "level", level.String())
+	dst[len(dst)-1] = '}' // Replace trailing comma
+	return dst
+}
+
+func (JSONEncoder) AppendLineBreak(dst []byte) []byte {
+	return append(dst, '\n')
+}
+
+func (JSONEncoder) fieldValue(fields []byte, key string) []byte {
+	return fieldValue(fields, key)
+}
+
+// contextField re-appends one Context field, so that the pre-serialized
+// context can be rebuilt when the logger's encoder changes.
+type contextField func(enc Encoder, dst []byte) []byte
+
+// transcodeJSON re-encodes a JSON record produced by JSONEncoder with enc.
+// It backs RecordEncoder for encoders used with OutputEncoder.
+func transcodeJSON(dst, record []byte, enc Encoder) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(record))
+	d.UseNumber()
+	if tok, err := d.Token(); err != nil || tok != json.Delim('{') {
+		return dst, errors.New("xmuslogger: record is not a JSON object")
+	}
+
+	var fields []byte
+	var msg string
+	var t time.Time
+	level := InfoLevel
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return dst, err
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			return dst, err
+		}
+
+		switch key {
+		case "message":
+			if json.Unmarshal(raw, &msg) == nil {
+				continue
+			}
+		case "time":
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				if parsed, err := time.Parse(time.RFC3339, s); err == nil {
+					t = parsed
+					continue
+				}
+			}
+		case "level":
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				if parsed, err := ParseLevel(s); err == nil {
+					level = parsed
+					continue
+				}
+			}
+		}
+		fields = appendJSONValue(fields, enc, key, raw)
+	}
+	return enc.AppendRecord(dst, fields, msg, t, level), nil
+}
+
+func appendJSONValue(dst []byte, enc Encoder, key string, raw []byte) []byte {
+	switch {
+	case len(raw) == 0:
+		return dst
+	case raw[0] == '"':
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return enc.AppendString(dst, key, s)
+		}
+	case string(raw) == "true" || string(raw) == "false":
+		return enc.AppendBool(dst, key, raw[0] == 't')
+	case raw[0] == '-' || (raw[0] >= '0' && raw[0] <= '9'):
+		if i, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+			return enc.AppendInt64(dst, key, i)
+		}
+	}
+	return enc.AppendJSON(dst, key, raw)
+}
@@ -272,3 +272,39 @@ func TestEventPoolEdgeCases(t *testing.T) {
 		}
 	})
 }
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestErrorHandler(t *testing.T) {
+	var handled []error
+	SetErrorHandler(func(err error) { handled = append(handled, err) })
+	defer SetErrorHandler(nil)
+
+	mockRemote := &mockRemoteWriter{writeError: errors.New("remote down")}
+	logger := New().Output(failingWriter{}).Remote(mockRemote)
+	logger.Info().Msg("lost")
+
+	if len(handled) != 2 {
+		t.Fatalf("Expected 2 handled errors, got %v", handled)
+	}
+	if handled[0].Error() != "disk full" || handled[1].Error() != "remote down" {
+		t.Errorf("Unexpected handled errors: %v", handled)
+	}
+}
+
+func TestSetErrorHandlerConcurrent(t *testing.T) {
+	defer SetErrorHandler(nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			handleError(errors.New("background failure"))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		SetErrorHandler(func(err error) {})
+	}
+	<-done
+}
@@ -1,8 +1,11 @@
 package xmuslogger
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"runtime"
+	"strconv"
 	"time"
 )
 
@@ -13,6 +16,13 @@ type Event struct {
 	level        Level
 	done         func(*Event)
 	async        bool
+	hooks        []Hook
+	redactor     *Redactor
+	dedup        *Deduper
+	discard      bool
+	stdlib       bool
+	enc          Encoder
+	contextLen   int // Length of the logger's context at the start of buf
 }
 
 // Field methods
@@ -20,7 +30,7 @@ func (e *Event) Str(key, val string) *Event {
 	if e == nil {
 		return e
 	}
-	e.buf = appendString(e.buf, key, val)
+	e.buf = e.enc.AppendString(e.buf, key, e.redactor.field(key, val))
 	return e
 }
 
@@ -28,7 +38,11 @@ func (e *Event) Int(key string, val int) *Event {
 	if e == nil {
 		return e
 	}
-	e.buf = appendInt(e.buf, key, val)
+	if e.redactor.matchKey(key) {
+		e.buf = e.enc.AppendString(e.buf, key, e.redactor.mask(strconv.Itoa(val)))
+		return e
+	}
+	e.buf = e.enc.AppendInt64(e.buf, key, int64(val))
 	return e
 }
 
@@ -36,7 +50,11 @@ func (e *Event) Int64(key string, val int64) *Event {
 	if e == nil {
 		return e
 	}
-	e.buf = appendInt64(e.buf, key, val)
+	if e.redactor.matchKey(key) {
+		e.buf = e.enc.AppendString(e.buf, key, e.redactor.mask(strconv.FormatInt(val, 10)))
+		return e
+	}
+	e.buf = e.enc.AppendInt64(e.buf, key, val)
 	return e
 }
 
@@ -44,7 +62,43 @@ func (e *Event) Bool(key string, val bool) *Event {
 	if e == nil {
 		return e
 	}
-	e.buf = appendBool(e.buf, key, val)
+	if e.redactor.matchKey(key) {
+		e.buf = e.enc.AppendString(e.buf, key, e.redactor.mask(strconv.FormatBool(val)))
+		return e
+	}
+	e.buf = e.enc.AppendBool(e.buf, key, val)
+	return e
+}
+
+// Secret writes a placeholder instead of val, or a stable fingerprint when
+// the logger redacts with RedactHash.
+func (e *Event) Secret(key, val string) *Event {
+	if e == nil {
+		return e
+	}
+	if e.redactor != nil && e.redactor.mode == RedactHash {
+		e.buf = e.enc.AppendString(e.buf, key, Secret(val).Fingerprint(e.redactor.salt))
+		return e
+	}
+	e.buf = e.enc.AppendString(e.buf, key, redactedValue)
+	return e
+}
+
+// Interface writes val encoded with encoding/json.
+func (e *Event) Interface(key string, val interface{}) *Event {
+	if e == nil {
+		return e
+	}
+	if e.redactor.matchKey(key) {
+		e.buf = e.enc.AppendString(e.buf, key, e.redactor.mask(fmt.Sprint(val)))
+		return e
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		e.buf = e.enc.AppendString(e.buf, key, err.Error())
+		return e
+	}
+	e.buf = e.enc.AppendJSON(e.buf, key, e.redactor.json(b))
 	return e
 }
 
@@ -52,7 +106,20 @@ func (e *Event) Err(err error) *Event {
 	if e == nil || err == nil {
 		return e
 	}
-	e.buf = appendString(e.buf, "error", err.Error())
+	if ee, ok := e.enc.(errorEncoder); ok {
+		e.buf = ee.appendError(e.buf, err, func(s string) string { return e.redactor.field("error", s) })
+		return e
+	}
+	e.buf = e.enc.AppendString(e.buf, "error", e.redactor.field("error", err.Error()))
+	return e
+}
+
+// Discard drops the event: nothing is written when Msg is called.
+func (e *Event) Discard() *Event {
+	if e == nil {
+		return e
+	}
+	e.discard = true
 	return e
 }
 
@@ -62,30 +129,46 @@ func (e *Event) Msg(msg string) {
 		return
 	}
 
-	e.buf = appendString(e.buf, "message", msg)
-	e.buf = appendTime(e.buf, "time", time.Now())
-	e.buf = appendString(e.buf, "level", e.level.String())
+	msg = e.redactor.text(msg)
+	for _, h := range e.hooks {
+		h.Run(e, e.level, msg)
+	}
+	if e.discard || (e.dedup != nil && !e.dedup.admit(e, msg)) {
+		if e.done != nil {
+			e.done(e)
+		}
+		return
+	}
+
+	if e.stdlib {
+		e.buf = e.enc.AppendString(e.buf, "source", "stdlib")
+	}
 
-	finalBuf := wrapJSON(e.buf)
+	record := e.enc.AppendRecord(make([]byte, 0, len(e.buf)+len(msg)+96), e.buf, msg, time.Now(), e.level)
+	n := len(record)
+	line := e.enc.AppendLineBreak(record)
 
 	// Write to local outputs
 	for _, w := range e.writers {
-		_, err := w.Write(append(finalBuf, '\n'))
-		if err != nil {
-			// TODO : handle error
+		if _, err := writeLevel(w, e.level, line); err != nil {
+			handleError(err)
 		}
 	}
 
 	// Write to remote
 	if e.remoteWriter != nil {
-		if e.async {
-			if err := e.remoteWriter.WriteAsync(finalBuf); err != nil {
-				// TODO : handle error
-			}
+		var err error
+		if lw, ok := e.remoteWriter.(levelRemoteWriter); ok {
+			err = lw.writeRemoteLevel(e.level, e.buf[:e.contextLen], line[:n])
+		} else if cw, ok := e.remoteWriter.(contextWriter); ok {
+			err = cw.writeContext(e.buf[:e.contextLen], line[:n], e.async)
+		} else if e.async {
+			err = e.remoteWriter.WriteAsync(line[:n])
 		} else {
-			if err := e.remoteWriter.Write(finalBuf); err != nil {
-				// TODO : handle error
-			}
+			err = e.remoteWriter.Write(line[:n])
+		}
+		if err != nil {
+			handleError(err)
 		}
 	}
 
@@ -119,12 +202,39 @@ func (l *Logger) newEvent(level Level) *Event {
 	if !l.enabled[level] {
 		return nil // Zero cost for disabled levels
 	}
+	if l.sampling != nil && !l.sampling.sample(level) {
+		return nil
+	}
+
+	e := l.event(level)
+	if l.caller {
+		// Skip newEvent and the level method
+		if _, file, line, ok := runtime.Caller(2); ok {
+			e.buf = e.enc.AppendString(e.buf, "caller", file+":"+strconv.Itoa(line))
+		}
+	}
+	if l.sampling != nil && l.sampling.count {
+		e.buf = e.enc.AppendInt64(e.buf, "sampled", int64(l.sampling.dropped[level].Swap(0)))
+	}
+	return e
+}
 
+// event builds an event without checking the level; the stdlib path uses
+// it directly since Print* calls are always logged.
+func (l *Logger) event(level Level) *Event {
 	e := getEvent()
 	e.level = level
+	l.mu.RLock()
 	e.writers = l.writers
 	e.remoteWriter = l.remoteWriter
+	l.mu.RUnlock()
 	e.async = l.async
+	e.hooks = l.hooks
+	e.redactor = l.redactor
+	e.dedup = l.dedup
+	e.stdlib = false
+	e.enc = l.encoder
+	e.contextLen = len(l.context)
 	e.done = putEvent
 
 	// Copy pre-serialized context
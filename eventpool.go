@@ -11,6 +11,7 @@ var eventPool = sync.Pool{
 func getEvent() *Event {
 	e := eventPool.Get().(*Event)
 	e.buf = e.buf[:0]
+	e.discard = false
 	return e
 }
 
@@ -0,0 +1,263 @@
+package xmuslogger
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+type FluentMode int8
+
+const (
+	FluentForward       FluentMode = iota // [tag, [[time, record], ...], option]
+	FluentPackedForward                   // [tag, bin(entries), option]
+)
+
+// FluentRemoteWriter is a RemoteWriter that sends records to Fluentd or
+// Fluent Bit using the forward protocol over TCP or a Unix socket. Records
+// are batched by tag into Forward or PackedForward messages. With acks
+// enabled every message carries a chunk ID and is resent until the server
+// acknowledges it, giving at-least-once delivery as long as the unacked
+// records fit in the buffer. Records must be JSON encoded.
+type FluentRemoteWriter struct {
+	conn       *netConn
+	tag        string
+	tagFields  []string
+	mode       FluentMode
+	ack        bool
+	retries    int
+	bufferSize int
+	batchSize  int
+	flushEvery time.Duration
+	batch      *batcher[fluentEntry]
+}
+
+type fluentEntry struct {
+	tag   string
+	entry []byte // Encoded [time, record]
+}
+
+type FluentOption func(*FluentRemoteWriter)
+
+// NewFluentRemoteWriter sends to addr over network, "tcp" or "unix".
+func NewFluentRemoteWriter(network, addr string, options ...FluentOption) *FluentRemoteWriter {
+	w := &FluentRemoteWriter{
+		conn:       &netConn{network: network, addr: addr, timeout: 5 * time.Second},
+		tag:        "xmuslogger",
+		retries:    3,
+		bufferSize: 10000,
+		batchSize:  100,
+		flushEvery: time.Second,
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+	w.batch = newBatcher(w.batchSize, w.flushEvery, w.send)
+	return w
+}
+
+// FluentTag sets the tag, or its prefix when FluentTagFields is used.
+func FluentTag(tag string) FluentOption {
+	return func(w *FluentRemoteWriter) {
+		w.tag = tag
+	}
+}
+
+// FluentTagFields appends the values of the given logger context fields to
+// the tag, e.g. FluentTag("app") and FluentTagFields("service") give
+// app.api for a logger created with With().Str("service", "api").
+func FluentTagFields(fields ...string) FluentOption {
+	return func(w *FluentRemoteWriter) {
+		w.tagFields = fields
+	}
+}
+
+func FluentWithMode(mode FluentMode) FluentOption {
+	return func(w *FluentRemoteWriter) {
+		w.mode = mode
+	}
+}
+
+// FluentAck requests an ack for every message and resends it up to
+// retries times when none arrives. The records of a message that is still
+// not acknowledged are kept and sent again with the next batch.
+func FluentAck(retries int) FluentOption {
+	return func(w *FluentRemoteWriter) {
+		w.ack = true
+		w.retries = retries
+	}
+}
+
+// FluentBatch sends up to size records per message, at least once per
+// interval.
+func FluentBatch(size int, interval time.Duration) FluentOption {
+	return func(w *FluentRemoteWriter) {
+		w.batchSize = size
+		w.flushEvery = interval
+	}
+}
+
+// FluentBuffer sets how many records are kept while unacknowledged, 10000
+// by default. Records that do not fit are dropped.
+func FluentBuffer(records int) FluentOption {
+	return func(w *FluentRemoteWriter) {
+		w.bufferSize = records
+	}
+}
+
+// FluentTimeout bounds dialing, each write and the wait for an ack.
+func FluentTimeout(timeout time.Duration) FluentOption {
+	return func(w *FluentRemoteWriter) {
+		w.conn.timeout = timeout
+	}
+}
+
+func (w *FluentRemoteWriter) Write(data []byte) error {
+	return w.writeContext(nil, data, false)
+}
+
+func (w *FluentRemoteWriter) WriteAsync(data []byte) error {
+	return w.writeContext(nil, data, true)
+}
+
+func (w *FluentRemoteWriter) writeContext(context, data []byte, async bool) error {
+	data, err := jsonRecord(data)
+	if err != nil {
+		return err
+	}
+	entry := appendMsgpackArrayHeader(nil, 2)
+	entry = appendMsgpackEventTime(entry, recordTime(data[1:len(data)-1]))
+	entry, err = appendMsgpackJSON(entry, data)
+	if err != nil {
+		return err
+	}
+	return w.batch.add(fluentEntry{tag: w.tagFor(jsonContext(context)), entry: entry}, async)
+}
+
+func (w *FluentRemoteWriter) Flush() error { return w.batch.flush() }
+
+func (w *FluentRemoteWriter) Close() error {
+	err := w.batch.close()
+	if cerr := w.conn.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (w *FluentRemoteWriter) tagFor(context []byte) string {
+	if len(w.tagFields) == 0 {
+		return w.tag
+	}
+	parts := []string{w.tag}
+	for _, f := range w.tagFields {
+		raw := fieldValue(context, f)
+		if raw == nil {
+			continue
+		}
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			s = string(raw)
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ".")
+}
+
+func (w *FluentRemoteWriter) send(batch []fluentEntry) error {
+	// One message per tag, in order of first appearance
+	var order []string
+	groups := make(map[string][]fluentEntry)
+	for _, e := range batch {
+		if _, ok := groups[e.tag]; !ok {
+			order = append(order, e.tag)
+		}
+		groups[e.tag] = append(groups[e.tag], e)
+	}
+
+	var errs []error
+	var unacked []fluentEntry
+	for _, tag := range order {
+		if err := w.sendMessage(tag, groups[tag]); err != nil {
+			errs = append(errs, err)
+			if w.ack {
+				unacked = append(unacked, groups[tag]...)
+			}
+		}
+	}
+	if len(unacked) > 0 {
+		if dropped := w.batch.requeue(unacked, w.bufferSize); dropped > 0 {
+			errs = append(errs, fmt.Errorf("xmuslogger: %d unacknowledged Fluent records dropped", dropped))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (w *FluentRemoteWriter) sendMessage(tag string, entries []fluentEntry) error {
+	var chunk string
+	options := 1
+	if w.ack {
+		var id [16]byte
+		rand.Read(id[:])
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+		options++
+	}
+
+	msg := appendMsgpackArrayHeader(nil, 3)
+	msg = appendMsgpackString(msg, tag)
+	if w.mode == FluentPackedForward {
+		var packed []byte
+		for _, e := range entries {
+			packed = append(packed, e.entry...)
+		}
+		msg = appendMsgpackBin(msg, packed)
+	} else {
+		msg = appendMsgpackArrayHeader(msg, len(entries))
+		for _, e := range entries {
+			msg = append(msg, e.entry...)
+		}
+	}
+	msg = appendMsgpackMapHeader(msg, options)
+	msg = appendMsgpackString(msg, "size")
+	msg = appendMsgpackInt(msg, int64(len(entries)))
+	if !w.ack {
+		return w.conn.exchange(msg, nil)
+	}
+	msg = appendMsgpackString(msg, "chunk")
+	msg = appendMsgpackString(msg, chunk)
+
+	readAck := func(conn net.Conn) error {
+		resp, err := readMsgpack(byteReader{conn})
+		if err != nil {
+			return err
+		}
+		if m, ok := resp.(map[string]interface{}); !ok || m["ack"] != chunk {
+			return fmt.Errorf("xmuslogger: unexpected Fluent ack %v", resp)
+		}
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt <= w.retries; attempt++ {
+		if err = w.conn.exchange(msg, readAck); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// byteReader reads without buffering, so nothing past the reply is consumed.
+type byteReader struct {
+	io.Reader
+}
+
+func (r byteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(r.Reader, b[:])
+	return b[0], err
+}
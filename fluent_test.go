@@ -0,0 +1,283 @@
+package xmuslogger
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fluentServer struct {
+	mu       sync.Mutex
+	messages [][]interface{}
+	dropAcks int // Number of acks to withhold
+}
+
+func serveFluent(t *testing.T, l net.Listener) *fluentServer {
+	t.Cleanup(func() { l.Close() })
+	s := &fluentServer{}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fluentServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		v, err := readMsgpack(r)
+		if err != nil {
+			return
+		}
+		msg, _ := v.([]interface{})
+		s.mu.Lock()
+		s.messages = append(s.messages, msg)
+		drop := s.dropAcks > 0
+		if drop {
+			s.dropAcks--
+		}
+		s.mu.Unlock()
+
+		if len(msg) != 3 {
+			continue
+		}
+		options, _ := msg[2].(map[string]interface{})
+		if chunk, ok := options["chunk"].(string); ok && !drop {
+			ack := appendMsgpackMapHeader(nil, 1)
+			ack = appendMsgpackString(ack, "ack")
+			conn.Write(appendMsgpackString(ack, chunk))
+		}
+	}
+}
+
+func (s *fluentServer) received(t *testing.T, n int) [][]interface{} {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		if len(s.messages) >= n {
+			msgs := s.messages
+			s.mu.Unlock()
+			return msgs
+		}
+		s.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d Fluent messages", n)
+	return nil
+}
+
+func TestFluentForwardUnix(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "fluent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	server := serveFluent(t, l)
+
+	w := NewFluentRemoteWriter("unix", sock, FluentTag("app"), FluentTagFields("service"), FluentBatch(10, time.Hour))
+	defer w.Close()
+	base := NewWithOutput(io.Discard).Remote(w)
+	api := base.With().Str("service", "api").Logger()
+
+	api.Info().Int("status", 200).Msg("one")
+	base.Warn().Msg("two")
+	api.Error().Msg("three")
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	msgs := server.received(t, 2)
+	if msgs[0][0] != "app.api" || msgs[1][0] != "app" {
+		t.Fatalf("Expected messages tagged app.api and app, got %v and %v", msgs[0][0], msgs[1][0])
+	}
+	entries := msgs[0][1].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries for app.api, got %d", len(entries))
+	}
+	entry := entries[0].([]interface{})
+	if ts, ok := entry[0].(time.Time); !ok || time.Since(ts) > time.Minute {
+		t.Errorf("Expected EventTime, got %v", entry[0])
+	}
+	record := entry[1].(map[string]interface{})
+	if record["message"] != "one" || record["status"] != int64(200) || record["service"] != "api" {
+		t.Errorf("Unexpected record %v", record)
+	}
+	if msgs[0][2].(map[string]interface{})["size"] != int64(2) {
+		t.Errorf("Expected size option, got %v", msgs[0][2])
+	}
+}
+
+func TestFluentRecordTime(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "fluent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	server := serveFluent(t, l)
+
+	w := NewFluentRemoteWriter("unix", sock)
+	defer w.Close()
+	if err := w.Write([]byte(`{"message":"old","time":"2020-01-02T03:04:05Z","level":"info"}`)); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	entry := server.received(t, 1)[0][1].([]interface{})[0].([]interface{})
+	want := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if ts, ok := entry[0].(time.Time); !ok || !ts.Equal(want) {
+		t.Errorf("Expected the record's time %v, got %v", want, entry[0])
+	}
+}
+
+func TestFluentPackedForwardAck(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := serveFluent(t, l)
+
+	w := NewFluentRemoteWriter("tcp", l.Addr().String(), FluentWithMode(FluentPackedForward), FluentAck(3))
+	defer w.Close()
+	w.Write([]byte(`{"message":"a"}`))
+	w.Write([]byte(`{"message":"b"}`))
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	msgs := server.received(t, 1)
+	packed, ok := msgs[0][1].([]byte)
+	if !ok {
+		t.Fatalf("Expected packed entries, got %T", msgs[0][1])
+	}
+	r := bytes.NewReader(packed)
+	for _, want := range []string{"a", "b"} {
+		v, err := readMsgpack(r)
+		if err != nil {
+			t.Fatalf("Invalid packed entry: %v", err)
+		}
+		if record := v.([]interface{})[1].(map[string]interface{}); record["message"] != want {
+			t.Errorf("Expected message %q, got %v", want, record)
+		}
+	}
+	if _, ok := msgs[0][2].(map[string]interface{})["chunk"].(string); !ok {
+		t.Errorf("Expected chunk option, got %v", msgs[0][2])
+	}
+}
+
+func TestFluentAckRetry(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := serveFluent(t, l)
+	server.dropAcks = 1
+
+	w := NewFluentRemoteWriter("tcp", l.Addr().String(), FluentAck(2), FluentTimeout(100*time.Millisecond))
+	defer w.Close()
+	w.Write([]byte(`{"message":"important"}`))
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Expected delivery after a lost ack, got %v", err)
+	}
+
+	msgs := server.received(t, 2)
+	first := msgs[0][2].(map[string]interface{})["chunk"]
+	second := msgs[1][2].(map[string]interface{})["chunk"]
+	if first != second {
+		t.Errorf("Expected the same chunk to be resent, got %v and %v", first, second)
+	}
+}
+
+func TestFluentAckKeepsUnackedRecords(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "fluent.sock")
+	w := NewFluentRemoteWriter("unix", addr, FluentAck(1), FluentTimeout(100*time.Millisecond))
+	defer w.Close()
+	w.Write([]byte(`{"message":"important"}`))
+
+	// Down for longer than the retries
+	for i := 0; i < 3; i++ {
+		if err := w.Flush(); err == nil {
+			t.Fatal("Expected Flush() to fail while the server is down")
+		}
+	}
+
+	l, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := serveFluent(t, l)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Expected delivery once the server is up, got %v", err)
+	}
+
+	msgs := server.received(t, 1)
+	entries := msgs[0][1].([]interface{})
+	if len(entries) != 1 || entries[0].([]interface{})[1].(map[string]interface{})["message"] != "important" {
+		t.Errorf("Expected the kept record, got %v", msgs[0])
+	}
+}
+
+func TestFluentBufferDropsOverflow(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "fluent.sock")
+	w := NewFluentRemoteWriter("unix", addr, FluentAck(0), FluentBuffer(1), FluentTimeout(100*time.Millisecond))
+	defer w.Close()
+	w.Write([]byte(`{"message":"a"}`))
+	w.Write([]byte(`{"message":"b"}`))
+	if err := w.Flush(); err == nil {
+		t.Fatal("Expected Flush() to fail while the server is down")
+	}
+
+	l, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := serveFluent(t, l)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+	msgs := server.received(t, 1)
+	if entries := msgs[0][1].([]interface{}); len(entries) != 1 {
+		t.Errorf("Expected 1 kept record, got %d", len(entries))
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	input := `{"s":"text","n":-42,"big":9007199254740993,"f":1.5,"b":true,"nil":null,"arr":[1,"x"],"obj":{"k":"v"}}`
+	encoded, err := appendMsgpackJSON(nil, []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := readMsgpack(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"s": "text", "n": int64(-42), "big": int64(9007199254740993), "f": 1.5, "b": true, "nil": nil,
+		"arr": []interface{}{int64(1), "x"}, "obj": map[string]interface{}{"k": "v"},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("Round trip mismatch:\n got %v\nwant %v", v, want)
+	}
+
+	for _, n := range []int64{0, 127, 128, -32, -33, math.MinInt16, math.MaxInt32 + 1, math.MinInt64} {
+		v, err := readMsgpack(bytes.NewReader(appendMsgpackInt(nil, n)))
+		if err != nil || v != n {
+			t.Errorf("Int %d decoded as %v (%v)", n, v, err)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+var errGELFTooLarge = errors.New("xmuslogger: GELF message needs more than 128 chunks")
+
+// GELFRemoteWriter is a RemoteWriter that sends GELF 1.1 messages to
+// Graylog over UDP or TCP. The message becomes short_message, the level a
+// syslog severity and every other field an additional "_" field. UDP
+// messages larger than the chunk size are split with GELF chunking and can
+// be compressed; TCP messages are null-byte terminated and never
+// compressed. Records must be JSON encoded.
+type GELFRemoteWriter struct {
+	conn       *netConn
+	host       string
+	compressor Compressor
+	chunkSize  int
+	batch      *batcher[[]byte]
+}
+
+type GELFOption func(*GELFRemoteWriter)
+
+// NewGELFRemoteWriter sends to addr over network, "udp" or "tcp".
+func NewGELFRemoteWriter(network, addr string, options ...GELFOption) *GELFRemoteWriter {
+	hostname, _ := os.Hostname()
+	w := &GELFRemoteWriter{
+		conn:      &netConn{network: network, addr: addr, timeout: 5 * time.Second},
+		host:      hostname,
+		chunkSize: 1420,
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+	w.batch = newBatcher(1, 0, w.send)
+	return w
+}
+
+func GELFHost(host string) GELFOption {
+	return func(w *GELFRemoteWriter) {
+		w.host = host
+	}
+}
+
+// GELFCompress compresses UDP messages, with GzipCompressor{} or
+// ZlibCompressor{}.
+func GELFCompress(c Compressor) GELFOption {
+	return func(w *GELFRemoteWriter) {
+		w.compressor = c
+	}
+}
+
+// GELFChunkSize sets the largest UDP datagram, 1420 bytes by default.
+func GELFChunkSize(size int) GELFOption {
+	return func(w *GELFRemoteWriter) {
+		w.chunkSize = size
+	}
+}
+
+// GELFTLS connects over TCP with TLS.
+func GELFTLS(config *tls.Config) GELFOption {
+	return func(w *GELFRemoteWriter) {
+		w.conn.tlsConfig = config
+	}
+}
+
+func (w *GELFRemoteWriter) Write(data []byte) error {
+	return w.write(data, false)
+}
+
+func (w *GELFRemoteWriter) WriteAsync(data []byte) error {
+	return w.write(data, true)
+}
+
+func (w *GELFRemoteWriter) Flush() error { return w.batch.flush() }
+
+func (w *GELFRemoteWriter) Close() error {
+	err := w.batch.close()
+	if cerr := w.conn.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (w *GELFRemoteWriter) write(data []byte, async bool) error {
+	msg, err := w.message(data, time.Now())
+	if err != nil {
+		return err
+	}
+	return w.batch.add(msg, async)
+}
+
+// message converts a JSON record into a GELF payload.
+func (w *GELFRemoteWriter) message(data []byte, t time.Time) ([]byte, error) {
+	fields, err := jsonFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := []byte(`{"version":"1.1",`)
+	m = appendString(m, "host", w.host)
+	m = append(m, `"timestamp":`...)
+	m = strconv.AppendFloat(m, float64(t.UnixMilli())/1e3, 'f', 3, 64)
+	m = append(m, ',')
+
+	shortMessage := []byte(`"-"`) // short_message must not be empty
+	severity := 6
+	for i := 0; i < len(fields); {
+		k, v, next := nextField(fields, i)
+		if next <= i {
+			break
+		}
+		i = next
+
+		switch string(k) {
+		case "message":
+			if string(v) != `""` {
+				shortMessage = v
+			}
+			continue
+		case "level":
+			var s string
+			if json.Unmarshal(v, &s) == nil {
+				if level, err := ParseLevel(s); err == nil {
+					severity = syslogSeverity(level)
+				}
+			}
+			continue
+		case "time":
+			continue // Replaced by timestamp
+		}
+
+		m = append(m, '"')
+		m = append(m, gelfFieldName(k)...)
+		m = append(m, '"', ':')
+		switch {
+		case string(v) == "null":
+			m = append(m, `""`...)
+		case v[0] == '"' || v[0] == '-' || (v[0] >= '0' && v[0] <= '9'):
+			m = append(m, v...)
+		default:
+			// Additional fields are strings or numbers; other values are
+			// sent as their JSON text.
+			m = appendQuoted(m, string(v))
+		}
+		m = append(m, ',')
+	}
+
+	m = append(m, `"short_message":`...)
+	m = append(m, shortMessage...)
+	m = append(m, `,"level":`...)
+	m = strconv.AppendInt(m, int64(severity), 10)
+	return append(m, '}'), nil
+}
+
+func (w *GELFRemoteWriter) send(batch [][]byte) error {
+	for _, msg := range batch {
+		if !w.conn.datagram() {
+			if err := w.conn.write(append(msg, 0)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if w.compressor != nil {
+			var buf bytes.Buffer
+			cw, err := w.compressor.NewWriter(&buf)
+			if err != nil {
+				return err
+			}
+			cw.Write(msg)
+			if err := cw.Close(); err != nil {
+				return err
+			}
+			msg = buf.Bytes()
+		}
+		chunks, err := gelfChunks(msg, w.chunkSize)
+		if err != nil {
+			return err
+		}
+		if err := w.conn.write(chunks...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gelfChunks splits msg into GELF chunks when it does not fit in one
+// datagram of size bytes.
+func gelfChunks(msg []byte, size int) ([][]byte, error) {
+	if len(msg) <= size {
+		return [][]byte{msg}, nil
+	}
+	payload := size - gelfChunkHeaderSize
+	if payload < 1 {
+		payload = 1
+	}
+	count := (len(msg) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return nil, errGELFTooLarge
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		binary.BigEndian.PutUint64(id[:], uint64(time.Now().UnixNano()))
+	}
+
+	chunks := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * payload
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk := make([]byte, 0, gelfChunkHeaderSize+end-seq*payload)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunks = append(chunks, append(chunk, msg[seq*payload:end]...))
+	}
+	return chunks, nil
+}
+
+// gelfFieldName prefixes key with '_' and replaces characters outside
+// [\w.-]. The reserved _id becomes __id.
+func gelfFieldName(key []byte) []byte {
+	name := make([]byte, 0, len(key)+2)
+	name = append(name, '_')
+	if string(key) == "id" {
+		name = append(name, '_')
+	}
+	for _, c := range key {
+		if !(c == '_' || c == '.' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			c = '_'
+		}
+		name = append(name, c)
+	}
+	return name
+}
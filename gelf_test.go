@@ -0,0 +1,183 @@
+package xmuslogger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listenGELF(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readDatagram(t *testing.T, conn net.PacketConn) []byte {
+	t.Helper()
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() returned error: %v", err)
+	}
+	return buf[:n]
+}
+
+func decodeGELF(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var msg map[string]interface{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Invalid GELF message %q: %v", data, err)
+	}
+	return msg
+}
+
+func TestGELFRemoteWriter(t *testing.T) {
+	conn := listenGELF(t)
+	w := NewGELFRemoteWriter("udp", conn.LocalAddr().String(), GELFHost("web-1"))
+	defer w.Close()
+
+	logger := NewWithOutput(io.Discard).Remote(w)
+	logger.Warn().Str("user", "john").Int("id", 7).Bool("cached", true).Str("http.method", "GET").Msg("slow request")
+
+	msg := decodeGELF(t, readDatagram(t, conn))
+	expected := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "web-1",
+		"short_message": "slow request",
+		"level":         float64(4),
+		"_user":         "john",
+		"__id":          float64(7),
+		"_cached":       "true",
+		"_http.method":  "GET",
+	}
+	for k, v := range expected {
+		if msg[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v, msg[k])
+		}
+	}
+	if ts, ok := msg["timestamp"].(float64); !ok || time.Since(time.Unix(int64(ts), 0)) > time.Minute {
+		t.Errorf("Unexpected timestamp %v", msg["timestamp"])
+	}
+	for _, k := range []string{"message", "time", "_level", "_time", "_message"} {
+		if _, ok := msg[k]; ok {
+			t.Errorf("Unexpected field %s in %v", k, msg)
+		}
+	}
+}
+
+func TestGELFChunking(t *testing.T) {
+	for name, c := range map[string]Compressor{"none": nil, "gzip": GzipCompressor{}, "zlib": ZlibCompressor{}} {
+		t.Run(name, func(t *testing.T) {
+			conn := listenGELF(t)
+			options := []GELFOption{GELFChunkSize(100)}
+			if c != nil {
+				options = append(options, GELFCompress(c))
+			}
+			w := NewGELFRemoteWriter("udp", conn.LocalAddr().String(), options...)
+			defer w.Close()
+
+			// Random-looking payload so compression still needs chunks
+			var long strings.Builder
+			for i := 0; long.Len() < 4000; i++ {
+				long.WriteString(time.Duration(i * 7919).String())
+			}
+			w.Write([]byte(`{"payload":"` + long.String() + `","message":"big","level":"info"}`))
+
+			first := readDatagram(t, conn)
+			if !bytes.HasPrefix(first, []byte{0x1e, 0x0f}) {
+				t.Fatalf("Expected a chunked message, got %q", first[:2])
+			}
+			count := int(first[11])
+			parts := make([][]byte, count)
+			for chunk := first; ; chunk = readDatagram(t, conn) {
+				if len(chunk) > 100 || !bytes.Equal(chunk[2:10], first[2:10]) {
+					t.Fatalf("Invalid chunk of %d bytes", len(chunk))
+				}
+				parts[chunk[10]] = chunk[12:]
+				count--
+				if count == 0 {
+					break
+				}
+			}
+
+			data := bytes.Join(parts, nil)
+			switch c.(type) {
+			case GzipCompressor:
+				r, err := gzip.NewReader(bytes.NewReader(data))
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, _ = io.ReadAll(r)
+			case ZlibCompressor:
+				r, err := zlib.NewReader(bytes.NewReader(data))
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, _ = io.ReadAll(r)
+			}
+			msg := decodeGELF(t, data)
+			if msg["short_message"] != "big" || msg["_payload"] != long.String() {
+				t.Errorf("Reassembled message does not match")
+			}
+		})
+	}
+}
+
+func TestGELFTooManyChunks(t *testing.T) {
+	if _, err := gelfChunks(make([]byte, 129*10), 22); err != errGELFTooLarge {
+		t.Errorf("Expected errGELFTooLarge, got %v", err)
+	}
+}
+
+func TestGELFTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	frames := make(chan []byte, 4)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			frame, err := r.ReadBytes(0)
+			if err != nil {
+				return
+			}
+			frames <- frame[:len(frame)-1]
+		}
+	}()
+
+	w := NewGELFRemoteWriter("tcp", l.Addr().String(), GELFCompress(GzipCompressor{}))
+	defer w.Close()
+	w.WriteAsync([]byte(`{"message":"one","level":"error"}`))
+	w.WriteAsync([]byte(`{"message":"","level":"info"}`))
+	w.Flush()
+
+	for _, want := range []string{"one", "-"} {
+		select {
+		case frame := <-frames:
+			if msg := decodeGELF(t, frame); msg["short_message"] != want {
+				t.Errorf("Expected short_message %q, got %v", want, msg["short_message"])
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for GELF frame")
+		}
+	}
+}
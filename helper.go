@@ -10,15 +10,25 @@ func (l *Logger) clone() *Logger {
 	defer l.mu.RUnlock()
 
 	newLogger := &Logger{
-		level:        l.level,
-		writers:      make([]io.Writer, len(l.writers)),
-		remoteWriter: l.remoteWriter,
-		context:      make([]byte, len(l.context)),
-		async:        l.async,
+		level:         l.level,
+		writers:       make([]io.Writer, len(l.writers)),
+		remoteWriter:  l.remoteWriter,
+		context:       make([]byte, len(l.context)),
+		contextFields: make([]contextField, len(l.contextFields)),
+		encoder:       l.encoder,
+		async:         l.async,
+		hooks:         make([]Hook, len(l.hooks)),
+		redactor:      l.redactor,
+		sampling:      l.sampling,
+		dedup:         l.dedup,
+		syncWrites:    l.syncWrites,
+		caller:        l.caller,
 	}
 
 	copy(newLogger.writers, l.writers)
 	copy(newLogger.context, l.context)
+	copy(newLogger.contextFields, l.contextFields)
+	copy(newLogger.hooks, l.hooks)
 
 	newLogger.Logger = log.New(&loggerWriter{parent: newLogger}, l.Prefix(), l.Flags())
 	newLogger.updateEnabledLevels()
@@ -0,0 +1,14 @@
+package xmuslogger
+
+// Hook is called for every event right before it is written. A hook may
+// add fields to the event or drop it entirely with Event.Discard.
+type Hook interface {
+	Run(e *Event, level Level, msg string)
+}
+
+// HookFunc adapts an ordinary function to the Hook interface.
+type HookFunc func(e *Event, level Level, msg string)
+
+func (f HookFunc) Run(e *Event, level Level, msg string) {
+	f(e, level, msg)
+}
@@ -0,0 +1,97 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHookAddsFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(&buf).Hook(HookFunc(func(e *Event, level Level, msg string) {
+		e.Str("hooked_level", level.String())
+	}))
+
+	logger.Warn().Msg("hook test")
+
+	logEntry, err := parseLogLine(strings.TrimSpace(buf.String()))
+	if err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	if logEntry["hooked_level"] != "warn" {
+		t.Errorf("Expected hooked_level=warn, got %v", logEntry["hooked_level"])
+	}
+	if logEntry["message"] != "hook test" {
+		t.Errorf("Expected message 'hook test', got %v", logEntry["message"])
+	}
+}
+
+func TestHookDiscard(t *testing.T) {
+	var buf bytes.Buffer
+	mockRemote := &mockRemoteWriter{}
+	logger := New().Output(&buf).Remote(mockRemote).Hook(HookFunc(func(e *Event, level Level, msg string) {
+		if msg == "drop me" {
+			e.Discard()
+		}
+	}))
+
+	logger.Info().Msg("drop me")
+	logger.Info().Msg("keep me")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 log line, got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], "keep me") {
+		t.Errorf("Expected kept message, got %s", lines[0])
+	}
+	if len(mockRemote.GetWrites()) != 1 {
+		t.Errorf("Expected 1 remote write, got %d", len(mockRemote.GetWrites()))
+	}
+}
+
+func TestHookStdlibPath(t *testing.T) {
+	var buf bytes.Buffer
+	var gotMsg string
+	logger := New().Output(&buf).Hook(HookFunc(func(e *Event, level Level, msg string) {
+		gotMsg = msg
+		e.Bool("hooked", true)
+	}))
+	logger.SetFlags(0)
+
+	logger.Printf("user %s", "john")
+
+	if gotMsg != "user john" {
+		t.Errorf("Expected hook to see 'user john', got %q", gotMsg)
+	}
+	logEntry, err := parseLogLine(strings.TrimSpace(buf.String()))
+	if err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	if logEntry["hooked"] != true {
+		t.Errorf("Expected hooked=true, got %v", logEntry["hooked"])
+	}
+	if logEntry["source"] != "stdlib" {
+		t.Errorf("Expected source 'stdlib', got %v", logEntry["source"])
+	}
+}
+
+func TestHookPropagation(t *testing.T) {
+	var buf bytes.Buffer
+	calls := 0
+	base := New().Output(&buf)
+	hooked := base.Hook(HookFunc(func(e *Event, level Level, msg string) {
+		calls++
+	}))
+
+	hooked.With().Str("service", "api").Logger().Info().Msg("from context")
+	hooked.clone().Info().Msg("from clone")
+	base.Info().Msg("from base")
+
+	if calls != 2 {
+		t.Errorf("Expected hook to run 2 times, got %d", calls)
+	}
+	if len(base.hooks) != 0 {
+		t.Error("Hook() should not modify the original logger")
+	}
+}
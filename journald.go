@@ -0,0 +1,202 @@
+package xmuslogger
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JournaldRemoteWriter is a RemoteWriter that sends records to
+// systemd-journald over its native protocol. The level becomes PRIORITY,
+// the message MESSAGE and the caller added by Logger.Caller CODE_FILE and
+// CODE_LINE; other fields are sent under their uppercased, sanitized
+// names. Records too large for a datagram are passed in a sealed memfd.
+// Records must be JSON encoded.
+type JournaldRemoteWriter struct {
+	addr       string
+	identifier string
+	batch      *batcher[[]byte]
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+type JournaldOption func(*JournaldRemoteWriter)
+
+// NewJournaldRemoteWriter sends to the local journal. The socket is opened
+// on first use.
+func NewJournaldRemoteWriter(options ...JournaldOption) *JournaldRemoteWriter {
+	w := &JournaldRemoteWriter{
+		addr:       "/run/systemd/journal/socket",
+		identifier: filepath.Base(os.Args[0]),
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+	w.batch = newBatcher(1, 0, w.send)
+	return w
+}
+
+// JournaldSocket sets the journal socket path.
+func JournaldSocket(path string) JournaldOption {
+	return func(w *JournaldRemoteWriter) {
+		w.addr = path
+	}
+}
+
+// JournaldIdentifier sets SYSLOG_IDENTIFIER, the program name by default.
+// An empty identifier omits the field.
+func JournaldIdentifier(identifier string) JournaldOption {
+	return func(w *JournaldRemoteWriter) {
+		w.identifier = identifier
+	}
+}
+
+func (w *JournaldRemoteWriter) Write(data []byte) error {
+	return w.write(data, false)
+}
+
+func (w *JournaldRemoteWriter) WriteAsync(data []byte) error {
+	return w.write(data, true)
+}
+
+func (w *JournaldRemoteWriter) Flush() error { return w.batch.flush() }
+
+func (w *JournaldRemoteWriter) Close() error {
+	err := w.batch.close()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		if cerr := w.conn.Close(); err == nil {
+			err = cerr
+		}
+		w.conn = nil
+	}
+	return err
+}
+
+func (w *JournaldRemoteWriter) write(data []byte, async bool) error {
+	fields, err := jsonFields(data)
+	if err != nil {
+		return err
+	}
+	return w.batch.add(w.message(fields), async)
+}
+
+// message encodes fields as journal entry fields.
+func (w *JournaldRemoteWriter) message(fields []byte) []byte {
+	priority := 6 // Informational
+	var m []byte
+	for i := 0; i < len(fields); {
+		k, v, next := nextField(fields, i)
+		if next <= i {
+			break
+		}
+		i = next
+
+		value := string(v)
+		if len(v) > 0 && v[0] == '"' {
+			json.Unmarshal(v, &value)
+		}
+
+		switch key := string(k); key {
+		case "level":
+			if level, err := ParseLevel(value); err == nil {
+				priority = syslogSeverity(level)
+				continue
+			}
+		case "time":
+			continue // The journal stamps entries on receipt
+		case "message":
+			m = appendJournalField(m, "MESSAGE", value)
+			continue
+		case "caller":
+			if file, line := splitCaller(value); line != "" {
+				m = appendJournalField(m, "CODE_FILE", file)
+				m = appendJournalField(m, "CODE_LINE", line)
+				continue
+			}
+		}
+		if name := journalFieldName(string(k)); name != "" {
+			m = appendJournalField(m, name, value)
+		}
+	}
+
+	m = appendJournalField(m, "PRIORITY", strconv.Itoa(priority))
+	if w.identifier != "" {
+		m = appendJournalField(m, "SYSLOG_IDENTIFIER", w.identifier)
+	}
+	return m
+}
+
+// appendJournalField uses the binary form for values with newlines.
+func appendJournalField(dst []byte, name, value string) []byte {
+	dst = append(dst, name...)
+	if !strings.Contains(value, "\n") {
+		dst = append(dst, '=')
+		dst = append(dst, value...)
+		return append(dst, '\n')
+	}
+	dst = append(dst, '\n')
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(len(value)))
+	dst = append(dst, value...)
+	return append(dst, '\n')
+}
+
+// journalFieldName uppercases key and replaces characters other than
+// letters, digits and underscores. Leading underscores and digits are
+// dropped, since those names are reserved, and names are cut at 64 bytes.
+// It returns "" if nothing is left.
+func journalFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(b) < 64; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9', c == '_':
+			if len(b) == 0 {
+				continue
+			}
+		default:
+			if len(b) == 0 {
+				continue
+			}
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+func (w *JournaldRemoteWriter) send(batch [][]byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: w.addr, Net: "unixgram"})
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	for _, m := range batch {
+		_, err := w.conn.Write(m)
+		if journalTooLarge(err) {
+			err = sendJournalFD(w.conn, m)
+		}
+		if err != nil {
+			// The journal may have restarted, dial again next time
+			w.conn.Close()
+			w.conn = nil
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package xmuslogger
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// memfdCreateTrap is the memfd_create syscall number, which the syscall
+// package only defines for some architectures.
+var memfdCreateTrap = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips":     4354,
+	"mipsle":   4354,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+func journalTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournalFD writes m to a sealed memfd and passes its descriptor to the
+// journal. Where memfds are unavailable an unlinked file in /dev/shm is
+// used instead, as sd-journal does.
+func sendJournalFD(conn *net.UnixConn, m []byte) error {
+	f, err := journalMemfd(m)
+	if err != nil {
+		if f, err = journalTempFile(m); err != nil {
+			return err
+		}
+	}
+	defer f.Close()
+
+	// WriteMsgUnix refuses connected datagram sockets, so call sendmsg directly
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	err = rc.Write(func(fd uintptr) bool {
+		serr = syscall.Sendmsg(int(fd), nil, syscall.UnixRights(int(f.Fd())), nil, 0)
+		return serr != syscall.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return serr
+}
+
+func journalMemfd(m []byte) (*os.File, error) {
+	trap, ok := memfdCreateTrap[runtime.GOARCH]
+	if !ok {
+		return nil, syscall.ENOSYS
+	}
+	name := []byte("journal-message\x00")
+	const cloexec, allowSealing = 0x1, 0x2
+	fd, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(&name[0])), cloexec|allowSealing, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	f := os.NewFile(fd, "journal-message")
+	if _, err := f.Write(m); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	const addSeals, sealAll = 1033, 0xf // F_ADD_SEALS; seal, shrink, grow, write
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, addSeals, sealAll); errno != 0 {
+		f.Close()
+		return nil, errno
+	}
+	return f, nil
+}
+
+func journalTempFile(m []byte) (*os.File, error) {
+	f, err := os.CreateTemp("/dev/shm", "journal-")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(f.Name())
+	if _, err := f.Write(m); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
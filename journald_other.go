@@ -0,0 +1,16 @@
+//go:build !linux
+
+package xmuslogger
+
+import (
+	"errors"
+	"net"
+)
+
+func journalTooLarge(err error) bool {
+	return false
+}
+
+func sendJournalFD(conn *net.UnixConn, m []byte) error {
+	return errors.New("xmuslogger: journald descriptor passing requires Linux")
+}
@@ -0,0 +1,201 @@
+//go:build linux
+
+package xmuslogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("Unix datagram sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadBuffer(1 << 20)
+	return conn, path
+}
+
+// readJournal receives one entry, following a passed descriptor if any.
+func readJournal(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	buf := make([]byte, 1<<16)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatalf("Failed to read journal entry: %v", err)
+	}
+	data := buf[:n]
+	if oobn > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := os.NewFile(uintptr(fds[0]), "memfd")
+		defer f.Close()
+		const getSeals, sealWrite = 1034, 0x8 // F_GET_SEALS, F_SEAL_WRITE
+		if seals, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), getSeals, 0); errno == 0 && seals&sealWrite == 0 {
+			t.Error("Expected a write-sealed memfd")
+		}
+		if data, err = io.ReadAll(io.NewSectionReader(f, 0, 1<<30)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return parseJournal(t, data)
+}
+
+func parseJournal(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		nl := bytes.IndexByte(data, '\n')
+		if nl < 0 {
+			t.Fatalf("Unterminated field %q", data)
+		}
+		line := data[:nl]
+		data = data[nl+1:]
+		if eq := bytes.IndexByte(line, '='); eq >= 0 {
+			fields[string(line[:eq])] = string(line[eq+1:])
+			continue
+		}
+		size := binary.LittleEndian.Uint64(data)
+		fields[string(line)] = string(data[8 : 8+size])
+		data = data[8+size+1:]
+	}
+	return fields
+}
+
+func TestJournaldRemoteWriter(t *testing.T) {
+	server, path := listenJournal(t)
+	w := NewJournaldRemoteWriter(JournaldSocket(path), JournaldIdentifier("shop"))
+	defer w.Close()
+	logger := NewWithOutput(io.Discard).Remote(w).With().Str("service", "api").Logger()
+
+	logger.Warn().
+		Str("caller", "handler.go:42").
+		Int("http.status-code", 503).
+		Str("_hidden", "x").
+		Str("9lives", "cat").
+		Msg("first line\nsecond line")
+
+	fields := readJournal(t, server)
+	expected := map[string]string{
+		"MESSAGE":           "first line\nsecond line",
+		"PRIORITY":          "4",
+		"CODE_FILE":         "handler.go",
+		"CODE_LINE":         "42",
+		"SERVICE":           "api",
+		"HTTP_STATUS_CODE":  "503",
+		"HIDDEN":            "x",
+		"LIVES":             "cat",
+		"SYSLOG_IDENTIFIER": "shop",
+	}
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("Field %s: expected %q, got %q", k, v, fields[k])
+		}
+	}
+	if _, ok := fields["TIME"]; ok {
+		t.Error("Expected time to be left to the journal")
+	}
+	if _, ok := fields["LEVEL"]; ok {
+		t.Error("Expected level to be sent as PRIORITY only")
+	}
+}
+
+func TestJournaldCaller(t *testing.T) {
+	server, path := listenJournal(t)
+	w := NewJournaldRemoteWriter(JournaldSocket(path))
+	defer w.Close()
+	logger := NewWithOutput(io.Discard).Remote(w).Caller()
+
+	_, file, line, _ := runtime.Caller(0)
+	logger.Info().Msg("here")
+
+	fields := readJournal(t, server)
+	if fields["CODE_FILE"] != file || fields["CODE_LINE"] != strconv.Itoa(line+1) {
+		t.Errorf("Expected CODE_FILE %s and CODE_LINE %d, got %q and %q", file, line+1, fields["CODE_FILE"], fields["CODE_LINE"])
+	}
+	if _, ok := fields["CALLER"]; ok {
+		t.Error("Expected caller to be sent as CODE_FILE and CODE_LINE only")
+	}
+}
+
+func TestJournaldLargePayload(t *testing.T) {
+	server, path := listenJournal(t)
+	w := NewJournaldRemoteWriter(JournaldSocket(path))
+	defer w.Close()
+
+	message := strings.Repeat("x", 4<<20)
+	if err := w.Write([]byte(`{"level":"error","message":"` + message + `"}`)); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	fields := readJournal(t, server)
+	if fields["MESSAGE"] != message || fields["PRIORITY"] != "3" {
+		t.Errorf("Large entry did not round trip, got %d byte message", len(fields["MESSAGE"]))
+	}
+}
+
+func TestJournaldReconnect(t *testing.T) {
+	old, path := listenJournal(t)
+	w := NewJournaldRemoteWriter(JournaldSocket(path))
+	defer w.Close()
+	if err := w.Write([]byte(`{"message":"a"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The journal restarts and binds a new socket at the same path
+	old.Close()
+	os.Remove(path)
+	if err := w.Write([]byte(`{"message":"lost"}`)); err == nil {
+		t.Error("Expected an error while the journal is down")
+	}
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	if err := w.Write([]byte(`{"message":"b"}`)); err != nil {
+		t.Fatalf("Expected the writer to reconnect, got %v", err)
+	}
+	if fields := readJournal(t, server); fields["MESSAGE"] != "b" {
+		t.Errorf("Unexpected entry %v", fields)
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":   "USER_ID",
+		"trace.id":  "TRACE_ID",
+		"__cursor":  "CURSOR",
+		"123":       "",
+		"ünicode":   "NICODE",
+		"kebab-key": "KEBAB_KEY",
+	}
+	for key, expected := range tests {
+		if got := journalFieldName(key); got != expected {
+			t.Errorf("journalFieldName(%q) = %q, expected %q", key, got, expected)
+		}
+	}
+	if got := journalFieldName(strings.Repeat("a", 100)); len(got) != 64 {
+		t.Errorf("Expected names cut at 64 bytes, got %d", len(got))
+	}
+}
@@ -0,0 +1,87 @@
+package xmuslogger
+
+import "io"
+
+// LevelWriter is an io.Writer that also receives the level of each record.
+// Event.Msg calls WriteLevel instead of Write on outputs implementing it.
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level Level, p []byte) (n int, err error)
+}
+
+type filteredLevelWriter struct {
+	min, max Level
+	w        io.Writer
+}
+
+// FilteredLevelWriter writes only records with a level between min and max,
+// inclusive. Records written without a level are passed through.
+func FilteredLevelWriter(min, max Level, w io.Writer) LevelWriter {
+	return &filteredLevelWriter{min: min, max: max, w: w}
+}
+
+func (f *filteredLevelWriter) Write(p []byte) (n int, err error) {
+	return f.w.Write(p)
+}
+
+func (f *filteredLevelWriter) WriteLevel(level Level, p []byte) (n int, err error) {
+	if level < f.min || level > f.max {
+		return len(p), nil
+	}
+	return writeLevel(f.w, level, p)
+}
+
+func (f *filteredLevelWriter) Flush() error {
+	if fl, ok := f.w.(flusher); ok {
+		return fl.Flush()
+	}
+	return nil
+}
+
+type multiLevelWriter struct {
+	writers []io.Writer
+}
+
+// MultiLevelWriter fans each record out to all writers, passing the level to
+// those that implement LevelWriter. A failing writer does not stop the others;
+// the first error is returned.
+func MultiLevelWriter(writers ...io.Writer) LevelWriter {
+	return &multiLevelWriter{writers: writers}
+}
+
+func (m *multiLevelWriter) Write(p []byte) (n int, err error) {
+	for _, w := range m.writers {
+		if _, werr := w.Write(p); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return len(p), err
+}
+
+func (m *multiLevelWriter) WriteLevel(level Level, p []byte) (n int, err error) {
+	for _, w := range m.writers {
+		if _, werr := writeLevel(w, level, p); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return len(p), err
+}
+
+func (m *multiLevelWriter) Flush() error {
+	var err error
+	for _, w := range m.writers {
+		if fl, ok := w.(flusher); ok {
+			if ferr := fl.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+	}
+	return err
+}
+
+func writeLevel(w io.Writer, level Level, p []byte) (n int, err error) {
+	if lw, ok := w.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return w.Write(p)
+}
@@ -0,0 +1,85 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type recordingLevelWriter struct {
+	levels []Level
+	buf    bytes.Buffer
+}
+
+func (r *recordingLevelWriter) Write(p []byte) (int, error) { return r.buf.Write(p) }
+
+func (r *recordingLevelWriter) WriteLevel(level Level, p []byte) (int, error) {
+	r.levels = append(r.levels, level)
+	return r.buf.Write(p)
+}
+
+func TestLevelWriterReceivesLevel(t *testing.T) {
+	rec := &recordingLevelWriter{}
+	logger := New().Output(rec).Level(DebugLevel)
+
+	logger.Debug().Msg("debug")
+	logger.Error().Msg("error")
+	logger.Print("stdlib")
+
+	expected := []Level{DebugLevel, ErrorLevel, InfoLevel}
+	if len(rec.levels) != len(expected) {
+		t.Fatalf("Expected %d WriteLevel calls, got %d", len(expected), len(rec.levels))
+	}
+	for i, level := range expected {
+		if rec.levels[i] != level {
+			t.Errorf("Call %d: expected level %v, got %v", i, level, rec.levels[i])
+		}
+	}
+}
+
+func TestMultiLevelWriterRouting(t *testing.T) {
+	var stdout, stderr, errFile bytes.Buffer
+	logger := New().Level(TraceLevel).Output(MultiLevelWriter(
+		FilteredLevelWriter(TraceLevel, WarnLevel, &stdout),
+		FilteredLevelWriter(ErrorLevel, FatalLevel, &stderr),
+		FilteredLevelWriter(ErrorLevel, FatalLevel, &errFile),
+	))
+
+	logger.Info().Msg("info message")
+	logger.Warn().Msg("warn message")
+	logger.Error().Msg("error message")
+
+	if strings.Count(stdout.String(), "\n") != 2 || strings.Contains(stdout.String(), "error message") {
+		t.Errorf("Unexpected stdout output: %s", stdout.String())
+	}
+	for name, buf := range map[string]*bytes.Buffer{"stderr": &stderr, "file": &errFile} {
+		if strings.Count(buf.String(), "\n") != 1 || !strings.Contains(buf.String(), "error message") {
+			t.Errorf("Unexpected %s output: %s", name, buf.String())
+		}
+	}
+}
+
+func TestMultiLevelWriterErrorIsolation(t *testing.T) {
+	var buf bytes.Buffer
+	w := MultiLevelWriter(failingWriter{}, &buf)
+
+	n, err := w.WriteLevel(InfoLevel, []byte("line\n"))
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected first error to be returned, got %v", err)
+	}
+	if n != 5 || buf.String() != "line\n" {
+		t.Errorf("Expected remaining writers to receive the record, got %q", buf.String())
+	}
+}
+
+func TestFilteredLevelWriterPlainWrite(t *testing.T) {
+	var buf bytes.Buffer
+	w := FilteredLevelWriter(ErrorLevel, FatalLevel, &buf)
+
+	w.Write([]byte("no level\n"))
+	w.WriteLevel(InfoLevel, []byte("filtered\n"))
+
+	if buf.String() != "no level\n" {
+		t.Errorf("Expected only the plain write to pass, got %q", buf.String())
+	}
+}
@@ -0,0 +1,131 @@
+package xmuslogger
+
+import (
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// LogfmtEncoder writes records as logfmt:
+//
+//	time=2026-10-18T10:30:00Z level=info msg="Server starting" port=8080
+//
+// Values are quoted when empty or when they contain spaces, '=', '"' or
+// control characters; keys have such characters replaced with '_'.
+type LogfmtEncoder struct{}
+
+func (LogfmtEncoder) AppendString(dst []byte, key, val string) []byte {
+	dst = appendLogfmtKey(dst, key)
+	dst = appendLogfmtValue(dst, val)
+	return append(dst, ' ')
+}
+
+func (LogfmtEncoder) AppendInt64(dst []byte, key string, val int64) []byte {
+	dst = appendLogfmtKey(dst, key)
+	dst = strconv.AppendInt(dst, val, 10)
+	return append(dst, ' ')
+}
+
+func (LogfmtEncoder) AppendBool(dst []byte, key string, val bool) []byte {
+	dst = appendLogfmtKey(dst, key)
+	dst = strconv.AppendBool(dst, val)
+	return append(dst, ' ')
+}
+
+func (LogfmtEncoder) AppendTime(dst []byte, key string, val time.Time) []byte {
+	dst = appendLogfmtKey(dst, key)
+	dst = val.AppendFormat(dst, time.RFC3339)
+	return append(dst, ' ')
+}
+
+func (LogfmtEncoder) AppendJSON(dst []byte, key string, val []byte) []byte {
+	dst = appendLogfmtKey(dst, key)
+	dst = appendLogfmtValue(dst, string(val))
+	return append(dst, ' ')
+}
+
+func (e LogfmtEncoder) AppendRecord(dst, fields []byte, msg string, t time.Time, level Level) []byte {
+	dst = e.AppendTime(dst, "time", t)
+	dst = e.AppendString(dst, "level", level.String())
+	dst = e.AppendString(dst, "msg", msg)
+	dst = append(dst, fields...)
+	return dst[:len(dst)-1] // Drop trailing space
+}
+
+func (LogfmtEncoder) AppendLineBreak(dst []byte) []byte {
+	return append(dst, '\n')
+}
+
+// EncodeRecord converts a JSON record, for use with OutputEncoder.
+func (e LogfmtEncoder) EncodeRecord(dst, record []byte) ([]byte, error) {
+	return transcodeJSON(dst, record, e)
+}
+
+func (LogfmtEncoder) fieldValue(fields []byte, key string) []byte {
+	for i := 0; i < len(fields); {
+		start := i
+		for i < len(fields) && fields[i] != '=' {
+			i++
+		}
+		k := fields[start:i]
+		i++ // Skip '='
+
+		valStart := i
+		if i < len(fields) && fields[i] == '"' {
+			for i++; i < len(fields) && fields[i] != '"'; i++ {
+				if fields[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		} else {
+			for i < len(fields) && fields[i] != ' ' {
+				i++
+			}
+		}
+		if i > len(fields) {
+			i = len(fields)
+		}
+		if string(k) == key {
+			return fields[valStart:i]
+		}
+		i++ // Skip ' '
+	}
+	return nil
+}
+
+func appendLogfmtKey(dst []byte, key string) []byte {
+	if key == "" {
+		return append(dst, '_', '=')
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			c = '_'
+		}
+		dst = append(dst, c)
+	}
+	return append(dst, '=')
+}
+
+func appendLogfmtValue(dst []byte, val string) []byte {
+	if !logfmtNeedsQuote(val) {
+		return append(dst, val...)
+	}
+	dst = append(dst, '"')
+	dst = appendEscapedString(dst, val)
+	return append(dst, '"')
+}
+
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+			return true
+		}
+	}
+	return !utf8.ValidString(s)
+}
@@ -0,0 +1,100 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).Encoder(LogfmtEncoder{})
+
+	logger.Warn().
+		Str("plain", "value").
+		Str("spaced", "hello world").
+		Str("quoted", `say "hi"`).
+		Str("multi", "a\nb").
+		Str("empty", "").
+		Str("bad key", "x").
+		Int("port", 8080).
+		Bool("tls", true).
+		Msg("Server starting")
+
+	line := buf.String()
+	if !strings.HasPrefix(line, "time=") || !strings.HasSuffix(line, "\n") {
+		t.Fatalf("Unexpected line shape: %q", line)
+	}
+	expected := ` level=warn msg="Server starting" plain=value spaced="hello world" quoted="say \"hi\"" multi="a\nb" empty="" bad_key=x port=8080 tls=true` + "\n"
+	if !strings.HasSuffix(line, expected) {
+		t.Errorf("Expected line to end with %q, got %q", expected, line)
+	}
+}
+
+func TestLogfmtEncoderContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).With().Str("service", "api").Int("instance", 1).Logger()
+
+	logger.Encoder(LogfmtEncoder{}).Info().Msg("switched")
+	logger.Info().Msg("json")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "msg=switched service=api instance=1") {
+		t.Errorf("Expected context re-encoded as logfmt, got %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], `{"service":"api","instance":1,`) {
+		t.Errorf("Expected original logger to keep JSON, got %q", lines[1])
+	}
+}
+
+func TestLogfmtOutputEncoder(t *testing.T) {
+	var jsonBuf, logfmtBuf bytes.Buffer
+	logger := NewWithOutput(&jsonBuf)
+	logger.AddOutput(&logfmtBuf, OutputEncoder(LogfmtEncoder{}))
+
+	logger.Error().Str("user", "john doe").Interface("tags", []string{"a"}).Msg("failed")
+
+	if !strings.Contains(jsonBuf.String(), `"user":"john doe"`) {
+		t.Errorf("Expected JSON output, got %q", jsonBuf.String())
+	}
+	expected := ` level=error msg=failed user="john doe" tags="[\"a\"]"` + "\n"
+	if !strings.HasSuffix(logfmtBuf.String(), expected) {
+		t.Errorf("Expected logfmt output ending with %q, got %q", expected, logfmtBuf.String())
+	}
+}
+
+func TestLogfmtDedup(t *testing.T) {
+	var buf SafeBuffer
+	logger := New().Output(&buf).Encoder(LogfmtEncoder{}).Dedup(NewDeduper(time.Hour, 10, "host"))
+
+	logger.Error().Str("host", "db 1").Msg("down")
+	logger.Error().Str("host", "db 1").Msg("down")
+	logger.Error().Str("host", "db2").Msg("down")
+	logger.Flush()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[2], `host="db 1" repeat_count=1 first_seen=`) {
+		t.Errorf("Expected logfmt summary, got %q", lines[2])
+	}
+}
+
+func BenchmarkLogfmtLogging(b *testing.B) {
+	logger := New().Output(io.Discard).Encoder(LogfmtEncoder{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info().
+			Str("key1", "value1").
+			Int("key2", 42).
+			Bool("key3", true).
+			Msg("benchmark test")
+	}
+}
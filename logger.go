@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 )
 
 type XmusLogger interface {
@@ -19,6 +20,9 @@ type XmusLogger interface {
 	Panicf(format string, v ...interface{})
 	Panicln(v ...interface{})
 	SetOutput(w io.Writer)
+	AddOutput(w io.Writer, options ...OutputOption)
+	RemoveOutput(w io.Writer)
+	Outputs() []io.Writer
 	SetFlags(flag int)
 	SetPrefix(prefix string)
 	Writer() io.Writer
@@ -36,8 +40,17 @@ type XmusLogger interface {
 	Level(level Level) *Logger
 	Output(w io.Writer) *Logger
 	Remote(w RemoteWriter) *Logger
+	AddRemote(w RemoteWriter, options ...RemoteOption)
+	RemoteStats() []RemoteStats
 	RemoteHTTP(endpoint string, options ...HTTPOption) *Logger
 	With() *Context
+	Hook(h Hook) *Logger
+	Redact(r *Redactor) *Logger
+	Sample(s Sampler, options ...SampleOption) *Logger
+	Dedup(d *Deduper) *Logger
+	Encoder(enc Encoder) *Logger
+	Synchronized() *Logger
+	Caller() *Logger
 
 	// Lifecycle
 	Flush() error
@@ -52,14 +65,22 @@ type RemoteWriter interface {
 }
 
 type Logger struct {
-	*log.Logger               // Embedded for compatibility
-	level        Level        // Current log level
-	writers      []io.Writer  // Local outputs
-	remoteWriter RemoteWriter // Remote output
-	context      []byte       // Pre-serialized context
-	async        bool         // Async remote sending
-	mu           sync.RWMutex // Thread safety
-	enabled      [8]bool      // Level cache
+	*log.Logger                  // Embedded for compatibility
+	level         Level          // Current log level
+	writers       []io.Writer    // Local outputs
+	remoteWriter  RemoteWriter   // Remote output
+	context       []byte         // Pre-serialized context
+	contextFields []contextField // Context fields, to re-serialize on encoder change
+	encoder       Encoder        // Record format
+	async         bool           // Async remote sending
+	hooks         []Hook         // Run before each event is written
+	redactor      *Redactor      // Sensitive field masking
+	sampling      *sampling      // Event sampling, shared by clones
+	dedup         *Deduper       // Repeated message suppression
+	syncWrites    bool           // Wrap outputs with SyncWriter
+	caller        bool           // Add the file:line of each logging call
+	mu            sync.RWMutex   // Thread safety
+	enabled       [8]bool        // Level cache
 }
 
 // Constructor
@@ -68,6 +89,7 @@ func New() *Logger {
 		level:   InfoLevel,
 		writers: []io.Writer{os.Stdout},
 		context: []byte{},
+		encoder: JSONEncoder{},
 	}
 
 	// Route standard logger through our JSON formatter
@@ -82,6 +104,7 @@ func NewWithOutput(w io.Writer) *Logger {
 		level:   InfoLevel,
 		writers: []io.Writer{w},
 		context: []byte{},
+		encoder: JSONEncoder{},
 	}
 
 	l.Logger = log.New(&loggerWriter{parent: l}, "", log.LstdFlags)
@@ -90,6 +113,25 @@ func NewWithOutput(w io.Writer) *Logger {
 	return l
 }
 
+var errorHandler atomic.Pointer[func(err error)]
+
+// SetErrorHandler sets the function called when a record cannot be written
+// to an output or remote writer, or when a writer fails in the background.
+// Errors are dropped when fn is nil. It is safe to call at any time.
+func SetErrorHandler(fn func(err error)) {
+	if fn == nil {
+		errorHandler.Store(nil)
+		return
+	}
+	errorHandler.Store(&fn)
+}
+
+func handleError(err error) {
+	if fn := errorHandler.Load(); fn != nil {
+		(*fn)(err)
+	}
+}
+
 // GLOBAL FUNCTIONS
 var std = New()
 
@@ -7,7 +7,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -608,11 +612,13 @@ func TestHTTPRemoteWriterCustomHeaders(t *testing.T) {
 }
 
 func TestRemoteHTTPMethod(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
 	var buf bytes.Buffer
-	endpoint := "https://example.com/logs"
-	logger := New().Output(&buf).RemoteHTTP(endpoint, WithHTTPAuth("token"))
+	logger := New().Output(&buf).RemoteHTTP(srv.URL, WithHTTPAuth("token"))
+	defer logger.Close()
 
-	// Should not panic (actual HTTP implementation is TODO)
 	logger.Info().Msg("http test")
 
 	if buf.Len() == 0 {
@@ -1681,3 +1687,24 @@ func TestEdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestCaller(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOutput(&buf).Caller().With().Str("service", "api").Logger()
+
+	_, file, line, _ := runtime.Caller(0)
+	logger.Info().Msg("here")
+	NewWithOutput(&buf).Info().Msg("no caller")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if want := file + ":" + strconv.Itoa(line+1); record["caller"] != want {
+		t.Errorf("Expected caller %q, got %v", want, record["caller"])
+	}
+	if strings.Contains(lines[1], `"caller"`) {
+		t.Errorf("Expected no caller without Caller(), got %s", lines[1])
+	}
+}
@@ -0,0 +1,268 @@
+package xmuslogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LokiRemoteWriter is a RemoteWriter that pushes records to Grafana Loki's
+// /loki/api/v1/push JSON API. Records are grouped into streams by the
+// values of the label fields, and entries are timestamped in nanoseconds
+// with the record's time. Records must be JSON encoded.
+type LokiRemoteWriter struct {
+	http         *HTTPRemoteWriter
+	url          string
+	labels       []string
+	staticLabels map[string]string
+	tenant       string
+	httpOptions  []HTTPOption
+	maxRetries   int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	maxStreams   int
+	batch        *batcher[lokiEntry]
+
+	mu      sync.Mutex
+	streams map[string]struct{} // Distinct label sets seen
+	warnAt  int                 // Stream count of the next cardinality warning
+}
+
+type lokiEntry struct {
+	stream []byte // Encoded label set, entries are grouped by it
+	ts     int64
+	line   []byte
+}
+
+type LokiOption func(*LokiRemoteWriter)
+
+// NewLokiRemoteWriter pushes to the Loki server at endpoint, e.g.
+// http://localhost:3100; /loki/api/v1/push is appended unless present.
+func NewLokiRemoteWriter(endpoint string, options ...LokiOption) *LokiRemoteWriter {
+	w := &LokiRemoteWriter{
+		url:          strings.TrimSuffix(endpoint, "/"),
+		labels:       []string{"level"},
+		staticLabels: make(map[string]string),
+		maxRetries:   5,
+		minBackoff:   500 * time.Millisecond,
+		maxBackoff:   30 * time.Second,
+		maxStreams:   1000,
+		streams:      make(map[string]struct{}),
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+	if !strings.HasSuffix(w.url, "/loki/api/v1/push") {
+		w.url += "/loki/api/v1/push"
+	}
+
+	w.http = NewHTTPRemoteWriter(endpoint, w.httpOptions...)
+	if w.tenant != "" {
+		w.http.headers["X-Scope-OrgID"] = w.tenant
+	}
+	w.batch = newBatcher(w.http.batchSize, w.http.flushInterval, w.send)
+	return w
+}
+
+// LokiLabels sets the fields used as stream labels, "level" by default.
+// Label names have characters Loki does not allow replaced with '_'.
+func LokiLabels(fields ...string) LokiOption {
+	return func(w *LokiRemoteWriter) {
+		w.labels = fields
+	}
+}
+
+// LokiStaticLabels adds labels to every stream, e.g. job or env.
+func LokiStaticLabels(labels map[string]string) LokiOption {
+	return func(w *LokiRemoteWriter) {
+		for k, v := range labels {
+			w.staticLabels[k] = v
+		}
+	}
+}
+
+// LokiTenant sets the X-Scope-OrgID header for multi-tenant Loki.
+func LokiTenant(id string) LokiOption {
+	return func(w *LokiRemoteWriter) {
+		w.tenant = id
+	}
+}
+
+// LokiRetry retries pushes rejected with 429 or a 5xx status up to max
+// times, backing off exponentially between min and max.
+func LokiRetry(retries int, min, max time.Duration) LokiOption {
+	return func(w *LokiRemoteWriter) {
+		w.maxRetries = retries
+		w.minBackoff = min
+		w.maxBackoff = max
+	}
+}
+
+// LokiMaxStreams sets how many distinct label sets may be seen before a
+// cardinality warning is reported through the error handler, see
+// SetErrorHandler. The warning repeats each time the count doubles.
+func LokiMaxStreams(n int) LokiOption {
+	return func(w *LokiRemoteWriter) {
+		w.maxStreams = n
+	}
+}
+
+// LokiHTTP configures the HTTP transport, e.g. WithHTTPAuth or WithHTTPBatch.
+func LokiHTTP(options ...HTTPOption) LokiOption {
+	return func(w *LokiRemoteWriter) {
+		w.httpOptions = append(w.httpOptions, options...)
+	}
+}
+
+func (w *LokiRemoteWriter) Write(data []byte) error {
+	return w.write(data, false)
+}
+
+func (w *LokiRemoteWriter) WriteAsync(data []byte) error {
+	return w.write(data, true)
+}
+
+func (w *LokiRemoteWriter) Flush() error { return w.batch.flush() }
+func (w *LokiRemoteWriter) Close() error { return w.batch.close() }
+
+func (w *LokiRemoteWriter) write(data []byte, async bool) error {
+	data, err := jsonRecord(data)
+	if err != nil {
+		return err
+	}
+	fields := data[1 : len(data)-1]
+	entry := lokiEntry{
+		stream: w.stream(fields),
+		ts:     recordTime(fields).UnixNano(),
+		line:   append([]byte(nil), data...),
+	}
+	w.checkCardinality(entry.stream)
+	return w.batch.add(entry, async)
+}
+
+// stream encodes the record's label set as a JSON object with sorted keys.
+func (w *LokiRemoteWriter) stream(fields []byte) []byte {
+	labels := make(map[string]string, len(w.labels)+len(w.staticLabels))
+	for k, v := range w.staticLabels {
+		labels[lokiLabelName(k)] = v
+	}
+	for _, key := range w.labels {
+		raw := fieldValue(fields, key)
+		if raw == nil {
+			continue
+		}
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			s = string(raw) // Numbers and booleans keep their JSON form
+		}
+		labels[lokiLabelName(key)] = s
+	}
+	if len(labels) == 0 {
+		labels["job"] = "xmuslogger" // Loki requires at least one label
+	}
+
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	dst := []byte{'{'}
+	for _, k := range names {
+		dst = appendString(dst, k, labels[k])
+	}
+	dst[len(dst)-1] = '}'
+	return dst
+}
+
+// checkCardinality warns when the number of streams first exceeds
+// maxStreams, and again each time it doubles.
+func (w *LokiRemoteWriter) checkCardinality(stream []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.streams[string(stream)]; ok {
+		return
+	}
+	w.streams[string(stream)] = struct{}{}
+	if w.warnAt == 0 {
+		w.warnAt = w.maxStreams + 1
+	}
+	if n := len(w.streams); n >= w.warnAt {
+		w.warnAt = 2 * n
+		handleError(fmt.Errorf("xmuslogger: Loki label cardinality reached %d streams, over the limit of %d, check label fields %v", n, w.maxStreams, w.labels))
+	}
+}
+
+func (w *LokiRemoteWriter) send(batch []lokiEntry) error {
+	// Group by stream in order of first appearance
+	var order []string
+	groups := make(map[string][]lokiEntry)
+	for _, e := range batch {
+		key := string(e.stream)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], e)
+	}
+
+	body := []byte(`{"streams":[`)
+	for i, key := range order {
+		entries := groups[key]
+		sort.SliceStable(entries, func(a, b int) bool { return entries[a].ts < entries[b].ts })
+
+		if i > 0 {
+			body = append(body, ',')
+		}
+		body = append(body, `{"stream":`...)
+		body = append(body, key...)
+		body = append(body, `,"values":[`...)
+		for j, e := range entries {
+			if j > 0 {
+				body = append(body, ',')
+			}
+			body = append(body, `["`...)
+			body = strconv.AppendInt(body, e.ts, 10)
+			body = append(body, `",`...)
+			body = appendQuoted(body, string(e.line))
+			body = append(body, ']')
+		}
+		body = append(body, "]}"...)
+	}
+	body = append(body, "]}"...)
+
+	return w.push(body)
+}
+
+// push retries on backpressure, honouring Retry-After.
+func (w *LokiRemoteWriter) push(body []byte) error {
+	return retryBackoff(w.maxRetries, w.minBackoff, w.maxBackoff, func() (bool, string, error) {
+		resp, err := w.http.post(w.url, "application/json", body)
+		if err != nil {
+			return false, "", err
+		}
+		status, retryAfter := resp.StatusCode, resp.Header.Get("Retry-After")
+		drainBody(resp)
+
+		if status/100 == 2 {
+			return false, "", nil
+		}
+		retryable := status == http.StatusTooManyRequests || status/100 == 5
+		return retryable, retryAfter, fmt.Errorf("xmuslogger: Loki push failed: %d %s", status, http.StatusText(status))
+	})
+}
+
+// lokiLabelName replaces characters outside [a-zA-Z0-9_].
+func lokiLabelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' && i > 0) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,187 @@
+package xmuslogger
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type lokiPush struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+type lokiServer struct {
+	mu       sync.Mutex
+	pushes   []lokiPush
+	tenants  []string
+	statuses []int // Responses to return, in order; then 204
+}
+
+func newLokiServer(t *testing.T) (*lokiServer, *httptest.Server) {
+	s := &lokiServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(s.statuses) > 0 {
+			status := s.statuses[0]
+			s.statuses = s.statuses[1:]
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			return
+		}
+
+		var push lokiPush
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &push); err != nil {
+			t.Errorf("Invalid push body %q: %v", body, err)
+		}
+		s.pushes = append(s.pushes, push)
+		s.tenants = append(s.tenants, r.Header.Get("X-Scope-OrgID"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func TestLokiRemoteWriter(t *testing.T) {
+	server, srv := newLokiServer(t)
+	w := NewLokiRemoteWriter(srv.URL,
+		LokiLabels("service", "level"),
+		LokiStaticLabels(map[string]string{"env": "test"}),
+		LokiTenant("team-a"),
+		LokiHTTP(WithHTTPBatch(100, time.Hour)),
+	)
+
+	logger := NewWithOutput(io.Discard).Remote(w).With().Str("service", "api").Logger()
+	logger.Info().Msg("first")
+	logger.Error().Int("code", 500).Msg("failed")
+	logger.Info().Msg("second")
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	if len(server.pushes) != 1 {
+		t.Fatalf("Expected 1 push, got %d", len(server.pushes))
+	}
+	if server.tenants[0] != "team-a" {
+		t.Errorf("Expected tenant header, got %q", server.tenants[0])
+	}
+
+	streams := server.pushes[0].Streams
+	if len(streams) != 2 {
+		t.Fatalf("Expected 2 streams, got %d", len(streams))
+	}
+	info := streams[0]
+	if info.Stream["service"] != "api" || info.Stream["level"] != "info" || info.Stream["env"] != "test" {
+		t.Errorf("Unexpected labels: %v", info.Stream)
+	}
+	if len(info.Values) != 2 || !strings.Contains(info.Values[0][1], `"message":"first"`) {
+		t.Fatalf("Unexpected info entries: %v", info.Values)
+	}
+
+	first, err1 := strconv.ParseInt(info.Values[0][0], 10, 64)
+	second, err2 := strconv.ParseInt(info.Values[1][0], 10, 64)
+	if err1 != nil || err2 != nil || first > second {
+		t.Errorf("Expected sorted nanosecond timestamps, got %v", info.Values)
+	}
+	if time.Since(time.Unix(0, first)) > time.Minute {
+		t.Errorf("Timestamp %d is not in nanoseconds", first)
+	}
+	if streams[1].Stream["level"] != "error" {
+		t.Errorf("Expected error stream, got %v", streams[1].Stream)
+	}
+}
+
+func TestLokiRemoteWriterBackpressure(t *testing.T) {
+	server, srv := newLokiServer(t)
+	server.statuses = []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}
+	w := NewLokiRemoteWriter(srv.URL, LokiRetry(3, time.Millisecond, 10*time.Millisecond))
+
+	if err := w.Write([]byte(`{"message":"x","level":"info"}`)); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Expected push to succeed after retries, got %v", err)
+	}
+	if len(server.pushes) != 1 {
+		t.Errorf("Expected 1 accepted push, got %d", len(server.pushes))
+	}
+
+	server.statuses = []int{429, 429, 429, 429}
+	w.Write([]byte(`{"message":"y","level":"info"}`))
+	if err := w.Flush(); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Expected 429 error once retries run out, got %v", err)
+	}
+}
+
+func TestLokiRemoteWriterCardinality(t *testing.T) {
+	var handled []error
+	SetErrorHandler(func(err error) { handled = append(handled, err) })
+	defer SetErrorHandler(nil)
+
+	_, srv := newLokiServer(t)
+	w := NewLokiRemoteWriter(srv.URL, LokiLabels("user"), LokiMaxStreams(2), LokiHTTP(WithHTTPBatch(100, time.Hour)))
+	defer w.Close()
+
+	logger := NewWithOutput(io.Discard).Remote(w)
+	for i := 0; i < 12; i++ {
+		logger.Info().Int("user", i%6).Msg("login")
+	}
+	if len(handled) != 2 {
+		t.Fatalf("Expected warnings at 3 and 6 streams, got %v", handled)
+	}
+	for i, n := range []string{"3 streams", "6 streams"} {
+		if !strings.Contains(handled[i].Error(), "cardinality reached "+n) {
+			t.Errorf("Expected a warning at %s, got %v", n, handled[i])
+		}
+	}
+
+	for i := 6; i < 12; i++ {
+		logger.Info().Int("user", i).Msg("login")
+	}
+	if len(handled) != 3 || !strings.Contains(handled[2].Error(), "12 streams") {
+		t.Errorf("Expected another warning once the streams doubled, got %v", handled)
+	}
+}
+
+func TestLokiRemoteWriterRecordTime(t *testing.T) {
+	server, srv := newLokiServer(t)
+	w := NewLokiRemoteWriter(srv.URL)
+	if err := w.Write([]byte(`{"message":"old","time":"2020-01-02T03:04:05Z","level":"info"}`)); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	want := strconv.FormatInt(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano(), 10)
+	if len(server.pushes) != 1 || server.pushes[0].Streams[0].Values[0][0] != want {
+		t.Errorf("Expected the record's time %s, got %v", want, server.pushes)
+	}
+}
+
+func TestLokiLabelName(t *testing.T) {
+	tests := map[string]string{
+		"service":      "service",
+		"service.name": "service_name",
+		"1st":          "_st",
+		"http-code":    "http_code",
+	}
+	for in, want := range tests {
+		if got := lokiLabelName(in); got != want {
+			t.Errorf("lokiLabelName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+var errMsgpackMalformed = errors.New("xmuslogger: malformed MessagePack")
+
+func appendMsgpackNil(dst []byte) []byte {
+	return append(dst, 0xc0)
+}
+
+func appendMsgpackBool(dst []byte, v bool) []byte {
+	if v {
+		return append(dst, 0xc3)
+	}
+	return append(dst, 0xc2)
+}
+
+func appendMsgpackInt(dst []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgpackUint(dst, uint64(v))
+	case v >= -32:
+		return append(dst, byte(v))
+	case v >= math.MinInt8:
+		return append(dst, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xd2), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(dst, 0xd3), uint64(v))
+}
+
+func appendMsgpackUint(dst []byte, v uint64) []byte {
+	switch {
+	case v < 128:
+		return append(dst, byte(v))
+	case v <= math.MaxUint8:
+		return append(dst, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xce), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(dst, 0xcf), v)
+}
+
+func appendMsgpackFloat(dst []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, 0xcb), math.Float64bits(v))
+}
+
+func appendMsgpackString(dst []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		dst = append(dst, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		dst = append(dst, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		dst = binary.BigEndian.AppendUint16(append(dst, 0xda), uint16(n))
+	default:
+		dst = binary.BigEndian.AppendUint32(append(dst, 0xdb), uint32(n))
+	}
+	return append(dst, s...)
+}
+
+func appendMsgpackBin(dst, b []byte) []byte {
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		dst = append(dst, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		dst = binary.BigEndian.AppendUint16(append(dst, 0xc5), uint16(n))
+	default:
+		dst = binary.BigEndian.AppendUint32(append(dst, 0xc6), uint32(n))
+	}
+	return append(dst, b...)
+}
+
+func appendMsgpackArrayHeader(dst []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(dst, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xdc), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(dst, 0xdd), uint32(n))
+}
+
+func appendMsgpackMapHeader(dst []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(dst, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xde), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(dst, 0xdf), uint32(n))
+}
+
+// appendMsgpackEventTime writes t as the Fluent EventTime extension (type 0).
+func appendMsgpackEventTime(dst []byte, t time.Time) []byte {
+	dst = append(dst, 0xd7, 0x00)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(dst, uint32(t.Nanosecond()))
+}
+
+// appendMsgpackJSON converts one JSON value, keeping object key order.
+func appendMsgpackJSON(dst, raw []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	return appendMsgpackJSONValue(dst, d)
+}
+
+func appendMsgpackJSONValue(dst []byte, d *json.Decoder) ([]byte, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return dst, err
+	}
+
+	switch v := tok.(type) {
+	case nil:
+		return appendMsgpackNil(dst), nil
+	case bool:
+		return appendMsgpackBool(dst, v), nil
+	case string:
+		return appendMsgpackString(dst, v), nil
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return appendMsgpackInt(dst, i), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return dst, err
+		}
+		return appendMsgpackFloat(dst, f), nil
+	}
+
+	// Objects and arrays: headers need the element count up front
+	var elems []byte
+	n := 0
+	delim := tok.(json.Delim)
+	for d.More() {
+		if delim == '{' {
+			key, err := d.Token()
+			if err != nil {
+				return dst, err
+			}
+			elems = appendMsgpackString(elems, key.(string))
+		}
+		if elems, err = appendMsgpackJSONValue(elems, d); err != nil {
+			return dst, err
+		}
+		n++
+	}
+	if _, err := d.Token(); err != nil { // Closing delimiter
+		return dst, err
+	}
+	if delim == '{' {
+		dst = appendMsgpackMapHeader(dst, n)
+	} else {
+		dst = appendMsgpackArrayHeader(dst, n)
+	}
+	return append(dst, elems...), nil
+}
+
+// readMsgpack decodes one value. Maps become map[string]interface{} and
+// the EventTime extension becomes time.Time.
+func readMsgpack(r cborReader) (interface{}, error) {
+	return readMsgpackDepth(r, 0)
+}
+
+func readMsgpackDepth(r cborReader, depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errMsgpackMalformed
+	}
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return readMsgpackMap(r, int(b&0x0f), depth)
+	case b&0xf0 == 0x90:
+		return readMsgpackArray(r, int(b&0x0f), depth)
+	case b&0xe0 == 0xa0:
+		s, err := readMsgpackBytes(r, uint64(b&0x1f))
+		return string(s), err
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackUint(r, 1<<(b-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, n)
+	case 0xca:
+		n, err := readMsgpackUint(r, 4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := readMsgpackUint(r, 8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := readMsgpackUint(r, 1<<(b-0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		n, err := readMsgpackUint(r, size)
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, err
+	case 0xd7:
+		ext, err := readMsgpackBytes(r, 9)
+		if err != nil {
+			return nil, err
+		}
+		if ext[0] != 0 {
+			return ext[1:], nil
+		}
+		sec := binary.BigEndian.Uint32(ext[1:5])
+		nsec := binary.BigEndian.Uint32(ext[5:9])
+		return time.Unix(int64(sec), int64(nsec)), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackUint(r, 1<<(b-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		s, err := readMsgpackBytes(r, n)
+		return string(s), err
+	case 0xdc, 0xdd:
+		n, err := readMsgpackUint(r, 2<<(b-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, int(n), depth)
+	case 0xde, 0xdf:
+		n, err := readMsgpackUint(r, 2<<(b-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(r, int(n), depth)
+	}
+	return nil, errMsgpackMalformed
+}
+
+func readMsgpackUint(r cborReader, size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:size]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	var n uint64
+	for _, c := range buf[:size] {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func readMsgpackBytes(r cborReader, n uint64) ([]byte, error) {
+	if n > cborMaxStringLen {
+		return nil, errMsgpackMalformed
+	}
+	b, err := io.ReadAll(io.LimitReader(r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(b)) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b, nil
+}
+
+func readMsgpackArray(r cborReader, n, depth int) ([]interface{}, error) {
+	var items []interface{}
+	for i := 0; i < n; i++ {
+		v, err := readMsgpackDepth(r, depth+1)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+func readMsgpackMap(r cborReader, n, depth int) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, min(n, 64))
+	for i := 0; i < n; i++ {
+		k, err := readMsgpackDepth(r, depth+1)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		v, err := readMsgpackDepth(r, depth+1)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		switch key := k.(type) {
+		case string:
+			m[key] = v
+		case []byte:
+			m[string(key)] = v
+		default:
+			return nil, errMsgpackMalformed
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,299 @@
+package xmuslogger
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// MultiRemoteWriter fans records out to several remote writers. Each remote
+// has its own queue and goroutine, so a slow or failing backend neither
+// blocks nor drops delivery to the others. When a remote's queue is full,
+// new records for it are dropped and counted in its stats.
+//
+// Logger.AddRemote shares the remotes of the logger's MultiRemoteWriter
+// with a new one. A shared remote is closed by the last MultiRemoteWriter
+// holding it; closing the others only flushes it.
+type MultiRemoteWriter struct {
+	mu      sync.RWMutex
+	remotes []*remote
+	closed  atomic.Bool
+}
+
+type RemoteOption func(*remote)
+
+// RemoteLevel sends only records at or above min to the remote.
+func RemoteLevel(min Level) RemoteOption {
+	return func(r *remote) {
+		r.min = min
+	}
+}
+
+// RemoteQueue sets how many records can wait for the remote, 1024 by
+// default.
+func RemoteQueue(size int) RemoteOption {
+	return func(r *remote) {
+		r.size = size
+	}
+}
+
+// RemoteStats counts the records sent to one remote writer.
+type RemoteStats struct {
+	Written   uint64 // Accepted by the remote writer
+	Failed    uint64 // Rejected by the remote writer
+	Dropped   uint64 // Discarded because the queue was full
+	Queued    int    // Waiting to be written
+	LastError error  // Most recent error from the remote writer
+}
+
+// NewMultiRemoteWriter fans out to writers with default options. Use Add
+// for per-remote options.
+func NewMultiRemoteWriter(writers ...RemoteWriter) *MultiRemoteWriter {
+	m := &MultiRemoteWriter{}
+	for _, w := range writers {
+		m.Add(w)
+	}
+	return m
+}
+
+// Add starts delivering records to w.
+func (m *MultiRemoteWriter) Add(w RemoteWriter, options ...RemoteOption) {
+	r := &remote{w: w, min: TraceLevel, size: 1024}
+	for _, opt := range options {
+		opt(r)
+	}
+	r.refs.Store(1)
+	r.start()
+
+	m.mu.Lock()
+	m.remotes = append(m.remotes, r)
+	m.mu.Unlock()
+}
+
+// Stats returns the counters of each remote, in the order they were added.
+func (m *MultiRemoteWriter) Stats() []RemoteStats {
+	remotes := m.snapshot()
+	stats := make([]RemoteStats, len(remotes))
+	for i, r := range remotes {
+		stats[i] = r.stats()
+	}
+	return stats
+}
+
+// Write queues data for every remote. Without a level, records are treated
+// as info.
+func (m *MultiRemoteWriter) Write(data []byte) error {
+	return m.writeRemoteLevel(InfoLevel, nil, data)
+}
+
+// WriteAsync is Write; remotes are always written in the background.
+func (m *MultiRemoteWriter) WriteAsync(data []byte) error {
+	return m.writeRemoteLevel(InfoLevel, nil, data)
+}
+
+func (m *MultiRemoteWriter) writeRemoteLevel(level Level, context, record []byte) error {
+	if m.closed.Load() {
+		return os.ErrClosed
+	}
+	remotes := m.snapshot()
+	if len(remotes) == 0 {
+		return nil
+	}
+	// The caller may reuse both buffers
+	rec := remoteRecord{data: append([]byte(nil), record...)}
+	if len(context) > 0 {
+		rec.context = append([]byte(nil), context...)
+	}
+
+	var errs []error
+	for _, r := range remotes {
+		if level >= r.min {
+			if err := r.enqueue(rec); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush waits until every record queued so far has been written, then
+// flushes the remotes in parallel.
+func (m *MultiRemoteWriter) Flush() error {
+	return m.each((*remote).flush)
+}
+
+// Close writes the queued records and closes the remotes no other
+// MultiRemoteWriter holds.
+func (m *MultiRemoteWriter) Close() error {
+	if !m.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	return m.each((*remote).release)
+}
+
+// share returns a MultiRemoteWriter holding the same remotes as m.
+func (m *MultiRemoteWriter) share() *MultiRemoteWriter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	shared := &MultiRemoteWriter{remotes: append([]*remote(nil), m.remotes...)}
+	for _, r := range shared.remotes {
+		r.refs.Add(1)
+	}
+	return shared
+}
+
+func (m *MultiRemoteWriter) each(fn func(r *remote) error) error {
+	remotes := m.snapshot()
+	errs := make([]error, len(remotes))
+	var wg sync.WaitGroup
+	for i, r := range remotes {
+		wg.Add(1)
+		go func(i int, r *remote) {
+			defer wg.Done()
+			errs[i] = fn(r)
+		}(i, r)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (m *MultiRemoteWriter) snapshot() []*remote {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.remotes
+}
+
+// remote is one destination of a MultiRemoteWriter.
+type remote struct {
+	w    RemoteWriter
+	min  Level
+	size int
+	refs atomic.Int32 // MultiRemoteWriters holding the remote
+
+	queue   chan remoteRecord
+	closeMu sync.RWMutex // Held for writing while the queue is closed
+	closed  bool
+	wg      sync.WaitGroup
+	once    sync.Once
+
+	written atomic.Uint64
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+	errMu   sync.Mutex
+	lastErr error
+}
+
+type remoteRecord struct {
+	context []byte
+	data    []byte
+	flushed chan struct{} // Set for flush markers
+}
+
+func (r *remote) start() {
+	if r.size < 1 {
+		r.size = 1
+	}
+	r.queue = make(chan remoteRecord, r.size)
+	r.wg.Add(1)
+	go r.run()
+}
+
+func (r *remote) enqueue(rec remoteRecord) error {
+	r.closeMu.RLock()
+	defer r.closeMu.RUnlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	select {
+	case r.queue <- rec:
+	default:
+		r.dropped.Add(1)
+	}
+	return nil
+}
+
+func (r *remote) run() {
+	defer r.wg.Done()
+	for rec := range r.queue {
+		if rec.flushed != nil {
+			close(rec.flushed)
+			continue
+		}
+
+		var err error
+		if cw, ok := r.w.(contextWriter); ok {
+			err = cw.writeContext(rec.context, rec.data, false)
+		} else {
+			err = r.w.Write(rec.data)
+		}
+		if err != nil {
+			r.failed.Add(1)
+			r.setErr(err)
+			handleError(err)
+		} else {
+			r.written.Add(1)
+		}
+	}
+}
+
+func (r *remote) flush() error {
+	r.closeMu.RLock()
+	if r.closed {
+		r.closeMu.RUnlock()
+		return nil
+	}
+	// Queued behind the pending records, so it waits for them
+	flushed := make(chan struct{})
+	r.queue <- remoteRecord{flushed: flushed}
+	r.closeMu.RUnlock()
+	<-flushed
+
+	err := r.w.Flush()
+	if err != nil {
+		r.setErr(err)
+	}
+	return err
+}
+
+// release drops one holder of r, closing it when none is left.
+func (r *remote) release() error {
+	if r.refs.Add(-1) > 0 {
+		return r.flush()
+	}
+	return r.close()
+}
+
+func (r *remote) close() error {
+	var err error
+	r.once.Do(func() {
+		r.closeMu.Lock()
+		r.closed = true
+		close(r.queue)
+		r.closeMu.Unlock()
+		r.wg.Wait()
+
+		if err = r.w.Close(); err != nil {
+			r.setErr(err)
+		}
+	})
+	return err
+}
+
+func (r *remote) setErr(err error) {
+	r.errMu.Lock()
+	r.lastErr = err
+	r.errMu.Unlock()
+}
+
+func (r *remote) stats() RemoteStats {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	return RemoteStats{
+		Written:   r.written.Load(),
+		Failed:    r.failed.Load(),
+		Dropped:   r.dropped.Load(),
+		Queued:    len(r.queue),
+		LastError: r.lastErr,
+	}
+}
@@ -0,0 +1,213 @@
+package xmuslogger
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingRemote holds every write until release is closed.
+type blockingRemote struct {
+	mockRemoteWriter
+	release chan struct{}
+}
+
+func (b *blockingRemote) Write(data []byte) error {
+	<-b.release
+	return b.mockRemoteWriter.Write(data)
+}
+
+type contextRemote struct {
+	mockRemoteWriter
+	contexts []string
+}
+
+func (c *contextRemote) writeContext(context, record []byte, async bool) error {
+	c.mu.Lock()
+	c.contexts = append(c.contexts, string(context))
+	c.mu.Unlock()
+	return c.mockRemoteWriter.Write(record)
+}
+
+func waitStats(t *testing.T, m *MultiRemoteWriter, i int, done func(RemoteStats) bool) RemoteStats {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s := m.Stats()[i]; done(s) {
+			return s
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for remote %d, stats %+v", i, m.Stats()[i])
+	return RemoteStats{}
+}
+
+func TestMultiRemoteWriterIsolation(t *testing.T) {
+	var handled []error
+	var handledMu sync.Mutex
+	SetErrorHandler(func(err error) {
+		handledMu.Lock()
+		handled = append(handled, err)
+		handledMu.Unlock()
+	})
+	defer SetErrorHandler(nil)
+
+	slow := &blockingRemote{release: make(chan struct{})}
+	failing := &mockRemoteWriter{writeError: errors.New("backend down")}
+	healthy := &mockRemoteWriter{}
+	multi := NewMultiRemoteWriter()
+	multi.Add(slow, RemoteQueue(2))
+	multi.Add(failing)
+	multi.Add(healthy)
+	logger := NewWithOutput(io.Discard).Remote(multi)
+
+	for i := 0; i < 5; i++ {
+		logger.Info().Int("n", i).Msg("event")
+	}
+
+	waitStats(t, multi, 2, func(s RemoteStats) bool { return s.Written == 5 })
+	failed := waitStats(t, multi, 1, func(s RemoteStats) bool { return s.Failed == 5 })
+	if failed.LastError == nil || failed.LastError.Error() != "backend down" {
+		t.Errorf("Expected last error to be recorded, got %v", failed.LastError)
+	}
+	if s := multi.Stats()[0]; s.Written != 0 || s.Dropped < 2 {
+		t.Errorf("Expected the slow remote to drop records once its queue filled, got %+v", s)
+	}
+
+	close(slow.release)
+	if err := multi.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	s := multi.Stats()[0]
+	if s.Written+s.Dropped != 5 || s.Queued != 0 {
+		t.Errorf("Expected every record written or dropped, got %+v", s)
+	}
+	handledMu.Lock()
+	defer handledMu.Unlock()
+	if len(handled) != 5 {
+		t.Errorf("Expected 5 handled write errors, got %d", len(handled))
+	}
+}
+
+func TestAddRemote(t *testing.T) {
+	all := &mockRemoteWriter{}
+	errorsOnly := &contextRemote{}
+	base := NewWithOutput(io.Discard).Remote(all)
+	logger := base.With().Str("service", "api").Logger()
+	logger.AddRemote(errorsOnly, RemoteLevel(ErrorLevel))
+
+	logger.Info().Msg("fine")
+	logger.Error().Msg("broken")
+	base.Info().Msg("base only")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	if got := len(all.GetWrites()); got != 3 {
+		t.Errorf("Expected 3 records for the existing remote, got %d", got)
+	}
+	writes := errorsOnly.GetWrites()
+	if len(writes) != 1 || !strings.Contains(string(writes[0]), `"message":"broken"`) {
+		t.Errorf("Expected only the error record, got %q", writes)
+	}
+	if len(errorsOnly.contexts) != 1 || errorsOnly.contexts[0] != `"service":"api",` {
+		t.Errorf("Expected the logger context to be forwarded, got %q", errorsOnly.contexts)
+	}
+	if _, ok := base.remoteWriter.(*mockRemoteWriter); !ok {
+		t.Error("AddRemote should not change the remote writer of other loggers")
+	}
+
+	stats := logger.RemoteStats()
+	if len(stats) != 2 || stats[0].Written != 2 || stats[1].Written != 1 {
+		t.Errorf("Expected stats for both remotes, got %+v", stats)
+	}
+	if base.RemoteStats() != nil {
+		t.Error("Expected no stats without AddRemote")
+	}
+}
+
+type closeCountRemote struct {
+	mockRemoteWriter
+	closes int
+}
+
+func (c *closeCountRemote) Close() error {
+	c.mu.Lock()
+	c.closes++
+	c.mu.Unlock()
+	return nil
+}
+
+func TestAddRemoteSharedClose(t *testing.T) {
+	shared := &closeCountRemote{}
+	added := &closeCountRemote{}
+	base := NewWithOutput(io.Discard)
+	base.AddRemote(shared)
+	logger := base.With().Str("service", "api").Logger()
+	logger.AddRemote(added)
+
+	if err := base.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	if err := base.remoteWriter.Write([]byte(`{}`)); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected os.ErrClosed after Close, got %v", err)
+	}
+	if shared.closes != 0 {
+		t.Error("Closing one logger should not close a remote another logger still holds")
+	}
+
+	logger.Info().Msg("still open")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	if got := len(shared.GetWrites()); got != 1 {
+		t.Errorf("Expected the shared remote to keep receiving records, got %d", got)
+	}
+	if shared.closes != 1 || added.closes != 1 {
+		t.Errorf("Expected each remote closed once, got %d and %d", shared.closes, added.closes)
+	}
+}
+
+type flushFailWriter struct {
+	err error
+}
+
+func (w flushFailWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w flushFailWriter) Flush() error                { return w.err }
+
+func TestLoggerFlushJoinsLocalAndRemoteErrors(t *testing.T) {
+	localErr, remoteErr := errors.New("local failed"), errors.New("remote failed")
+	logger := NewWithOutput(flushFailWriter{localErr}).
+		Remote(&mockRemoteWriter{flushError: remoteErr, closeError: remoteErr})
+
+	if err := logger.Flush(); !errors.Is(err, localErr) || !errors.Is(err, remoteErr) {
+		t.Errorf("Flush(): expected both errors, got %v", err)
+	}
+	if err := logger.Close(); !errors.Is(err, localErr) || !errors.Is(err, remoteErr) {
+		t.Errorf("Close(): expected both errors, got %v", err)
+	}
+}
+
+func TestMultiRemoteWriterJoinsErrors(t *testing.T) {
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	multi := NewMultiRemoteWriter(
+		&mockRemoteWriter{flushError: errA, closeError: errA},
+		&mockRemoteWriter{},
+		&mockRemoteWriter{flushError: errB, closeError: errB},
+	)
+	logger := New().Remote(multi)
+
+	if err := logger.Flush(); !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Flush(): expected both errors, got %v", err)
+	}
+	if err := logger.Close(); !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Close(): expected both errors, got %v", err)
+	}
+	if err := multi.Write([]byte(`{}`)); !errors.Is(err, os.ErrClosed) {
+		t.Error("Expected an error writing to a closed MultiRemoteWriter")
+	}
+}
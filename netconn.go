@@ -0,0 +1,86 @@
+package xmuslogger
+
+import (
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+)
+
+// netConn is a lazily dialed connection that is re-established when a
+// write fails.
+type netConn struct {
+	network   string
+	addr      string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (c *netConn) datagram() bool {
+	switch c.network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return true
+	}
+	return false
+}
+
+// write sends each frame in its own Write.
+func (c *netConn) write(frames ...[]byte) error {
+	for _, frame := range frames {
+		if err := c.exchange(frame, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exchange writes frame and, when read is set, lets it consume the reply.
+// If either fails the connection is re-established and frame sent again once.
+func (c *netConn) exchange(frame []byte, read func(conn net.Conn) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.conn == nil {
+			if c.conn, err = c.dial(); err != nil {
+				return err
+			}
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+		if _, err = c.conn.Write(frame); err == nil {
+			if read == nil {
+				return nil
+			}
+			c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+			if err = read(c.conn); err == nil {
+				return nil
+			}
+		}
+		c.conn.Close()
+		c.conn = nil
+	}
+	return err
+}
+
+func (c *netConn) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	if c.tlsConfig != nil {
+		return tls.DialWithDialer(dialer, c.network, c.addr, c.tlsConfig)
+	}
+	return dialer.Dial(c.network, c.addr)
+}
+
+func (c *netConn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
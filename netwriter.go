@@ -0,0 +1,253 @@
+package xmuslogger
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var errNetBufferFull = errors.New("xmuslogger: NetWriter buffer full, record dropped")
+
+// NetWriter sends newline-delimited records to a TCP, UDP or Unix socket.
+// Write only buffers records in memory; a background goroutine dials on
+// the first write and sends them in order. While the connection is down,
+// records stay buffered and reconnects back off exponentially between
+// tries.
+//
+// NetWriter is an io.Writer for Logger.Output. Use AsRemote to pass it to
+// Logger.Remote.
+type NetWriter struct {
+	conn       *netConn
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	bufferSize int
+
+	mu       sync.Mutex
+	pending  [][]byte // Records waiting to be sent
+	buffered int      // Bytes in pending
+	backoff  time.Duration
+	retryAt  time.Time
+	closed   bool
+
+	sendMu  sync.Mutex // Held while sending pending records
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+type NetOption func(*NetWriter)
+
+// NewNetWriter sends to addr over network, e.g. "tcp", "udp" or "unix".
+func NewNetWriter(network, addr string, options ...NetOption) *NetWriter {
+	w := &NetWriter{
+		conn:       &netConn{network: network, addr: addr, timeout: 5 * time.Second},
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+		bufferSize: 1 << 20,
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+	go w.run()
+	return w
+}
+
+// NetTLS connects over TCP with TLS.
+func NetTLS(config *tls.Config) NetOption {
+	return func(w *NetWriter) {
+		w.conn.tlsConfig = config
+	}
+}
+
+// NetTimeout bounds dialing and each write, 5 seconds by default.
+func NetTimeout(timeout time.Duration) NetOption {
+	return func(w *NetWriter) {
+		w.conn.timeout = timeout
+	}
+}
+
+// NetBackoff sets the wait after a failed connection, doubling from min up
+// to max. The defaults are 100ms and 30s.
+func NetBackoff(min, max time.Duration) NetOption {
+	return func(w *NetWriter) {
+		w.minBackoff = min
+		w.maxBackoff = max
+	}
+}
+
+// NetBuffer sets how many bytes of records are kept until they are sent,
+// 1MB by default. Records that do not fit are dropped.
+func NetBuffer(size int) NetOption {
+	return func(w *NetWriter) {
+		w.bufferSize = size
+	}
+}
+
+// Write buffers p for the background goroutine and never blocks on the
+// network.
+func (w *NetWriter) Write(p []byte) (int, error) {
+	line := make([]byte, len(p), len(p)+1)
+	copy(line, p) // p may be reused by the caller
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		line = append(line, '\n')
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, os.ErrClosed
+	}
+	if w.buffered+len(line) > w.bufferSize {
+		w.mu.Unlock()
+		return 0, errNetBufferFull
+	}
+	w.pending = append(w.pending, line)
+	w.buffered += len(line)
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default: // Already woken
+	}
+	return len(p), nil
+}
+
+// Flush sends buffered records, reconnecting without waiting for the
+// backoff.
+func (w *NetWriter) Flush() error {
+	w.mu.Lock()
+	empty := len(w.pending) == 0
+	w.mu.Unlock()
+	if empty {
+		return nil
+	}
+	return w.drain()
+}
+
+// Close stops the background goroutine, sends buffered records and closes
+// the connection.
+func (w *NetWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+	close(w.done)
+	<-w.stopped
+
+	var err error
+	if derr := w.drain(); derr != nil {
+		w.mu.Lock()
+		err = fmt.Errorf("xmuslogger: %d buffered records not delivered: %w", len(w.pending), derr)
+		w.mu.Unlock()
+	}
+	if cerr := w.conn.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// run sends records as they are written, retrying once the backoff has
+// elapsed. Errors go to the error handler.
+func (w *NetWriter) run() {
+	defer close(w.stopped)
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.wake:
+		case <-retry:
+			retry = nil
+		}
+
+		w.mu.Lock()
+		empty, wait := len(w.pending) == 0, time.Until(w.retryAt)
+		w.mu.Unlock()
+		if empty {
+			continue
+		}
+		if wait <= 0 {
+			err := w.drain()
+			if err == nil {
+				continue
+			}
+			handleError(err)
+			w.mu.Lock()
+			wait = time.Until(w.retryAt)
+			w.mu.Unlock()
+		}
+		retry = time.After(wait)
+	}
+}
+
+// drain sends the buffered records in order. On failure the next
+// background attempt is delayed by the backoff.
+func (w *NetWriter) drain() error {
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+	for {
+		w.mu.Lock()
+		if len(w.pending) == 0 {
+			w.backoff = 0
+			w.mu.Unlock()
+			return nil
+		}
+		line := w.pending[0]
+		w.mu.Unlock()
+
+		if err := w.conn.write(line); err != nil {
+			w.mu.Lock()
+			w.fail()
+			w.mu.Unlock()
+			return err
+		}
+		// Only drain removes records, so line is still the first
+		w.mu.Lock()
+		w.buffered -= len(line)
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+		w.mu.Unlock()
+	}
+}
+
+// fail backs off the next attempt. The caller must hold mu.
+func (w *NetWriter) fail() {
+	if w.backoff == 0 {
+		w.backoff = w.minBackoff
+	} else if w.backoff *= 2; w.backoff > w.maxBackoff {
+		w.backoff = w.maxBackoff
+	}
+	w.retryAt = time.Now().Add(w.backoff)
+}
+
+// AsRemote returns w as a RemoteWriter, sharing its connection and buffer.
+func (w *NetWriter) AsRemote() RemoteWriter {
+	return netRemoteWriter{w}
+}
+
+type netRemoteWriter struct {
+	w *NetWriter
+}
+
+func (r netRemoteWriter) Write(data []byte) error {
+	_, err := r.w.Write(data)
+	return err
+}
+
+// WriteAsync is Write, which already sends in the background.
+func (r netRemoteWriter) WriteAsync(data []byte) error {
+	_, err := r.w.Write(data)
+	return err
+}
+
+func (r netRemoteWriter) Flush() error { return r.w.Flush() }
+func (r netRemoteWriter) Close() error { return r.w.Close() }
@@ -0,0 +1,230 @@
+package xmuslogger
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type lineServer struct {
+	mu      sync.Mutex
+	lines   []string
+	accepts atomic.Int32
+}
+
+func serveLines(t *testing.T, l net.Listener) *lineServer {
+	t.Cleanup(func() { l.Close() })
+	s := &lineServer{}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.accepts.Add(1)
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					s.mu.Lock()
+					s.lines = append(s.lines, scanner.Text())
+					s.mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+func (s *lineServer) received(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		if len(s.lines) >= n {
+			lines := append([]string(nil), s.lines...)
+			s.mu.Unlock()
+			return lines
+		}
+		s.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d lines", n)
+	return nil
+}
+
+func TestNetWriterOutputAndRemote(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := serveLines(t, l)
+
+	w := NewNetWriter("tcp", l.Addr().String())
+	defer w.Close()
+	time.Sleep(20 * time.Millisecond)
+	if server.accepts.Load() != 0 {
+		t.Fatal("Expected no connection before the first write")
+	}
+
+	NewWithOutput(w).Info().Msg("via output")
+	NewWithOutput(io.Discard).Remote(w.AsRemote()).Warn().Msg("via remote")
+
+	lines := server.received(t, 2)
+	if !strings.Contains(lines[0], `"message":"via output"`) || !strings.Contains(lines[1], `"message":"via remote"`) {
+		t.Errorf("Unexpected lines %q", lines)
+	}
+	if server.accepts.Load() != 1 {
+		t.Errorf("Expected one shared connection, got %d", server.accepts.Load())
+	}
+}
+
+func TestNetWriterBuffersWhileDisconnected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	w := NewNetWriter("unix", path, NetBackoff(time.Hour, time.Hour))
+	defer w.Close()
+
+	handled := make(chan error, 10)
+	SetErrorHandler(func(err error) { handled <- err })
+	defer SetErrorHandler(nil)
+
+	for _, msg := range []string{"one", "two", "three"} {
+		if _, err := w.Write([]byte(msg)); err != nil {
+			t.Fatalf("Write(%q) returned error: %v", msg, err)
+		}
+	}
+	select {
+	case <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a connection error")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if len(handled) != 0 {
+		t.Errorf("Expected one connection error within the backoff, got %d more", len(handled))
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	server := serveLines(t, l)
+
+	// Still backing off, so this is buffered too
+	w.Write([]byte("four"))
+	time.Sleep(20 * time.Millisecond)
+	if server.accepts.Load() != 0 {
+		t.Error("Expected no reconnect before the backoff elapsed")
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+	lines := server.received(t, 4)
+	if strings.Join(lines, ",") != "one,two,three,four" {
+		t.Errorf("Expected buffered records in order, got %q", lines)
+	}
+}
+
+func TestNetWriterReconnectAfterBackoff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	w := NewNetWriter("unix", path, NetBackoff(10*time.Millisecond, 10*time.Millisecond))
+	defer w.Close()
+
+	w.Write([]byte("early"))
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	server := serveLines(t, l)
+	time.Sleep(20 * time.Millisecond)
+
+	w.Write([]byte("late"))
+	if lines := server.received(t, 2); lines[0] != "early" || lines[1] != "late" {
+		t.Errorf("Unexpected lines %q", lines)
+	}
+}
+
+func TestNetWriterWriteDoesNotBlock(t *testing.T) {
+	w := NewNetWriter("unix", filepath.Join(t.TempDir(), "missing.sock"), NetBackoff(time.Hour, time.Hour))
+	defer w.Close()
+
+	// A send in progress, e.g. a slow dial, must not hold up writers
+	w.sendMu.Lock()
+	done := make(chan struct{})
+	go func() {
+		w.Write([]byte("one"))
+		w.Write([]byte("two"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Write blocked on the connection")
+	}
+	w.sendMu.Unlock()
+}
+
+func TestNetWriterBufferLimit(t *testing.T) {
+	w := NewNetWriter("unix", filepath.Join(t.TempDir(), "missing.sock"), NetBuffer(10), NetBackoff(time.Hour, time.Hour))
+
+	if _, err := w.Write([]byte("12345678")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("overflow")); !errors.Is(err, errNetBufferFull) {
+		t.Errorf("Expected errNetBufferFull, got %v", err)
+	}
+	if err := w.Close(); err == nil || !strings.Contains(err.Error(), "1 buffered records") {
+		t.Errorf("Expected undelivered records to be reported, got %v", err)
+	}
+	if _, err := w.Write([]byte("closed")); err == nil {
+		t.Error("Expected error writing to a closed NetWriter")
+	}
+}
+
+func TestNetWriterUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	w := NewNetWriter("udp", pc.LocalAddr().String())
+	defer w.Close()
+	w.Write([]byte(`{"message":"datagram"}`))
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[:n]) != "{\"message\":\"datagram\"}\n" {
+		t.Errorf("Unexpected datagram %q", buf[:n])
+	}
+}
+
+func TestNetWriterTLS(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := serveLines(t, l)
+
+	w := NewNetWriter("tcp", l.Addr().String(), NetTLS(&tls.Config{RootCAs: pool}), NetTimeout(time.Second))
+	defer w.Close()
+	if _, err := w.Write([]byte("secure")); err != nil {
+		t.Fatal(err)
+	}
+	if lines := server.received(t, 1); lines[0] != "secure" {
+		t.Errorf("Unexpected lines %q", lines)
+	}
+}
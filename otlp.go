@@ -0,0 +1,298 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const otlpScopeName = "github.com/amupxm/xmus-logger"
+
+// OTLPWriter is a RemoteWriter that exports records to an OpenTelemetry
+// collector as OTLP/HTTP JSON ExportLogsServiceRequests, without the SDK.
+// Levels map to SeverityNumber and SeverityText, trace_id and span_id fields
+// to traceId and spanId, the logger's context to resource attributes and
+// the remaining fields to log attributes. Records must be JSON encoded.
+type OTLPWriter struct {
+	http  *HTTPRemoteWriter
+	url   string
+	batch *batcher[otlpRecord]
+}
+
+type otlpRecord struct {
+	resource []byte // Encoded resource attributes, records are grouped by it
+	record   []byte // Encoded LogRecord
+}
+
+// NewOTLPWriter exports to the collector at endpoint, e.g.
+// http://localhost:4318; /v1/logs is appended unless already present.
+// Batching is configured with WithHTTPBatch.
+func NewOTLPWriter(endpoint string, options ...HTTPOption) *OTLPWriter {
+	w := &OTLPWriter{
+		http: NewHTTPRemoteWriter(endpoint, options...),
+		url:  strings.TrimSuffix(endpoint, "/"),
+	}
+	if !strings.HasSuffix(w.url, "/v1/logs") {
+		w.url += "/v1/logs"
+	}
+	w.batch = newBatcher(w.http.batchSize, w.http.flushInterval, w.send)
+	return w
+}
+
+func (w *OTLPWriter) Write(data []byte) error {
+	return w.writeContext(nil, data, false)
+}
+
+func (w *OTLPWriter) WriteAsync(data []byte) error {
+	return w.writeContext(nil, data, true)
+}
+
+func (w *OTLPWriter) writeContext(context, data []byte, async bool) error {
+	rec, err := newOTLPRecord(jsonContext(context), data)
+	if err != nil {
+		return err
+	}
+	return w.batch.add(rec, async)
+}
+
+func (w *OTLPWriter) Flush() error { return w.batch.flush() }
+func (w *OTLPWriter) Close() error { return w.batch.close() }
+
+func (w *OTLPWriter) send(batch []otlpRecord) error {
+	body := []byte(`{"resourceLogs":[`)
+	done := make([]bool, len(batch))
+	for i := range batch {
+		if done[i] {
+			continue
+		}
+		if body[len(body)-1] != '[' {
+			body = append(body, ',')
+		}
+		body = append(body, `{"resource":{"attributes":`...)
+		body = append(body, batch[i].resource...)
+		body = append(body, `},"scopeLogs":[{"scope":{"name":"`+otlpScopeName+`"},"logRecords":[`...)
+		for j := i; j < len(batch); j++ {
+			if done[j] || !bytes.Equal(batch[j].resource, batch[i].resource) {
+				continue
+			}
+			if j > i {
+				body = append(body, ',')
+			}
+			body = append(body, batch[j].record...)
+			done[j] = true
+		}
+		body = append(body, "]}]}"...)
+	}
+	body = append(body, "]}"...)
+
+	resp, err := w.http.post(w.url, "application/json", body)
+	if err != nil {
+		return err
+	}
+	defer drainBody(resp)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("xmuslogger: OTLP export failed: %s", resp.Status)
+	}
+	return nil
+}
+
+func newOTLPRecord(context, data []byte) (otlpRecord, error) {
+	fields, err := jsonFields(data)
+	if err != nil {
+		return otlpRecord{}, err
+	}
+
+	rec := otlpRecord{resource: []byte("[]")}
+	if len(context) > 0 && bytes.HasPrefix(fields, context) {
+		rec.resource = appendOTLPAttributes(nil, context, nil)
+		fields = fields[len(context):]
+	}
+
+	var body, traceID, spanID []byte
+	var ts time.Time
+	level := InfoLevel
+	attrs := appendOTLPAttributes(nil, fields, func(key, val []byte) bool {
+		switch string(key) {
+		case "message":
+			body = val
+		case "time":
+			var s string
+			if json.Unmarshal(val, &s) != nil {
+				return false
+			}
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return false
+			}
+			ts = t
+		case "level":
+			var s string
+			if json.Unmarshal(val, &s) != nil {
+				return false
+			}
+			l, err := ParseLevel(s)
+			if err != nil {
+				return false
+			}
+			level = l
+		case "trace_id", "traceId", "trace.id":
+			traceID = val
+		case "span_id", "spanId", "span.id":
+			spanID = val
+		default:
+			return false
+		}
+		return true
+	})
+
+	r := []byte(`{"timeUnixNano":"`)
+	if !ts.IsZero() {
+		r = strconv.AppendInt(r, ts.UnixNano(), 10)
+	} else {
+		r = append(r, '0')
+	}
+	r = append(r, `","observedTimeUnixNano":"`...)
+	r = strconv.AppendInt(r, time.Now().UnixNano(), 10)
+	r = append(r, `","severityNumber":`...)
+	r = strconv.AppendInt(r, int64(otlpSeverity(level)), 10)
+	r = append(r, `,"severityText":"`...)
+	r = append(r, strings.ToUpper(level.String())...)
+	r = append(r, '"')
+	if body != nil {
+		r = append(r, `,"body":`...)
+		r = appendOTLPValue(r, body)
+	}
+	r = append(r, `,"attributes":`...)
+	r = append(r, attrs...)
+	if traceID != nil {
+		r = append(r, `,"traceId":`...)
+		r = append(r, traceID...)
+	}
+	if spanID != nil {
+		r = append(r, `,"spanId":`...)
+		r = append(r, spanID...)
+	}
+	rec.record = append(r, '}')
+	return rec, nil
+}
+
+// otlpSeverity returns the first SeverityNumber of level's range.
+func otlpSeverity(level Level) int {
+	switch level {
+	case TraceLevel:
+		return 1
+	case DebugLevel:
+		return 5
+	case InfoLevel:
+		return 9
+	case WarnLevel:
+		return 13
+	case ErrorLevel:
+		return 17
+	}
+	return 21 // Fatal
+}
+
+// appendOTLPAttributes converts JSON fields into a KeyValue array. Fields
+// for which skip returns true are left out.
+func appendOTLPAttributes(dst, fields []byte, skip func(key, val []byte) bool) []byte {
+	dst = append(dst, '[')
+	first := true
+	for i := 0; i < len(fields); {
+		k, v, next := nextField(fields, i)
+		if next <= i {
+			break
+		}
+		i = next
+		if skip != nil && skip(k, v) {
+			continue
+		}
+		if !first {
+			dst = append(dst, ',')
+		}
+		first = false
+		dst = appendOTLPKeyValue(dst, k, v)
+	}
+	return append(dst, ']')
+}
+
+// appendOTLPKeyValue writes a KeyValue; key is already escaped.
+func appendOTLPKeyValue(dst, key, val []byte) []byte {
+	dst = append(dst, `{"key":"`...)
+	dst = append(dst, key...)
+	dst = append(dst, `","value":`...)
+	dst = appendOTLPValue(dst, val)
+	return append(dst, '}')
+}
+
+// appendOTLPValue converts a JSON value into an AnyValue.
+func appendOTLPValue(dst, val []byte) []byte {
+	val = bytes.TrimSpace(val)
+	if len(val) == 0 {
+		return append(dst, "{}"...)
+	}
+
+	switch c := val[0]; {
+	case c == '"':
+		dst = append(dst, `{"stringValue":`...)
+		dst = append(dst, val...)
+	case c == 't' || c == 'f':
+		dst = append(dst, `{"boolValue":`...)
+		dst = append(dst, val...)
+	case c == 'n':
+		return append(dst, "{}"...)
+	case c == '[':
+		var items []json.RawMessage
+		if json.Unmarshal(val, &items) != nil {
+			return append(dst, "{}"...)
+		}
+		dst = append(dst, `{"arrayValue":{"values":[`...)
+		for i, item := range items {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendOTLPValue(dst, item)
+		}
+		dst = append(dst, "]}"...)
+	case c == '{':
+		return appendOTLPObject(dst, val)
+	case bytes.ContainsAny(val, ".eE"):
+		dst = append(dst, `{"doubleValue":`...)
+		dst = append(dst, val...)
+	default:
+		// int64 is a string in the protobuf JSON mapping
+		dst = append(dst, `{"intValue":"`...)
+		dst = append(dst, val...)
+		dst = append(dst, '"')
+	}
+	return append(dst, '}')
+}
+
+// appendOTLPObject converts a JSON object into a kvlistValue, keeping key order.
+func appendOTLPObject(dst, val []byte) []byte {
+	d := json.NewDecoder(bytes.NewReader(val))
+	if _, err := d.Token(); err != nil {
+		return append(dst, "{}"...)
+	}
+
+	dst = append(dst, `{"kvlistValue":{"values":[`...)
+	for first := true; d.More(); first = false {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		var item json.RawMessage
+		if err := d.Decode(&item); err != nil {
+			break
+		}
+		if !first {
+			dst = append(dst, ',')
+		}
+		key := appendEscapedString(nil, tok.(string))
+		dst = appendOTLPKeyValue(dst, key, item)
+	}
+	return append(dst, "]}}"...)
+}
@@ -0,0 +1,150 @@
+package xmuslogger
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type otlpCollector struct {
+	mu       sync.Mutex
+	requests []map[string]interface{}
+	paths    []string
+	headers  []http.Header
+	status   int
+}
+
+func newOTLPCollector(t *testing.T) (*otlpCollector, *httptest.Server) {
+	c := &otlpCollector{status: http.StatusOK}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]interface{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("Collector received invalid JSON %q: %v", body, err)
+		}
+		c.mu.Lock()
+		c.requests = append(c.requests, req)
+		c.paths = append(c.paths, r.URL.Path)
+		c.headers = append(c.headers, r.Header.Clone())
+		status := c.status
+		c.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+func TestOTLPWriter(t *testing.T) {
+	collector, srv := newOTLPCollector(t)
+	w := NewOTLPWriter(srv.URL, WithHTTPAuth("token"), WithHTTPBatch(10, time.Hour))
+
+	logger := NewWithOutput(io.Discard).Remote(w).
+		With().Str("service.name", "checkout").Logger()
+	logger.Warn().
+		Str("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736").
+		Str("span_id", "00f067aa0ba902b7").
+		Int("attempt", 3).
+		Bool("retry", true).
+		Interface("meta", map[string]interface{}{"region": "eu"}).
+		Msg("payment slow")
+
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+	if len(collector.requests) != 1 {
+		t.Fatalf("Expected 1 export request, got %d", len(collector.requests))
+	}
+	if collector.paths[0] != "/v1/logs" {
+		t.Errorf("Expected POST to /v1/logs, got %s", collector.paths[0])
+	}
+	if got := collector.headers[0].Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Expected auth header, got %q", got)
+	}
+
+	resourceLogs := collector.requests[0]["resourceLogs"].([]interface{})[0].(map[string]interface{})
+	resAttrs := resourceLogs["resource"].(map[string]interface{})["attributes"].([]interface{})
+	if len(resAttrs) != 1 || resAttrs[0].(map[string]interface{})["key"] != "service.name" {
+		t.Errorf("Expected context as resource attributes, got %v", resAttrs)
+	}
+
+	record := resourceLogs["scopeLogs"].([]interface{})[0].(map[string]interface{})["logRecords"].([]interface{})[0].(map[string]interface{})
+	if record["severityNumber"] != float64(13) || record["severityText"] != "WARN" {
+		t.Errorf("Unexpected severity: %v %v", record["severityNumber"], record["severityText"])
+	}
+	if record["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" || record["spanId"] != "00f067aa0ba902b7" {
+		t.Errorf("Unexpected trace context: %v %v", record["traceId"], record["spanId"])
+	}
+	if record["body"].(map[string]interface{})["stringValue"] != "payment slow" {
+		t.Errorf("Unexpected body: %v", record["body"])
+	}
+	if record["timeUnixNano"] == "0" {
+		t.Error("Expected timeUnixNano to be set")
+	}
+
+	attrs := make(map[string]interface{})
+	for _, a := range record["attributes"].([]interface{}) {
+		kv := a.(map[string]interface{})
+		attrs[kv["key"].(string)] = kv["value"]
+	}
+	if len(attrs) != 3 {
+		t.Errorf("Expected 3 attributes, got %v", attrs)
+	}
+	if attrs["attempt"].(map[string]interface{})["intValue"] != "3" {
+		t.Errorf("Unexpected int attribute: %v", attrs["attempt"])
+	}
+	if attrs["retry"].(map[string]interface{})["boolValue"] != true {
+		t.Errorf("Unexpected bool attribute: %v", attrs["retry"])
+	}
+	if _, ok := attrs["meta"].(map[string]interface{})["kvlistValue"]; !ok {
+		t.Errorf("Expected kvlist attribute, got %v", attrs["meta"])
+	}
+}
+
+func TestOTLPWriterBatching(t *testing.T) {
+	collector, srv := newOTLPCollector(t)
+	w := NewOTLPWriter(srv.URL+"/v1/logs", WithHTTPBatch(2, time.Hour))
+	logger := NewWithOutput(io.Discard).Remote(w)
+	other := logger.With().Str("service.name", "other").Logger()
+
+	logger.Info().Msg("one")
+	other.Info().Msg("two")
+	logger.Info().Msg("three")
+
+	collector.mu.Lock()
+	if len(collector.requests) != 1 {
+		t.Errorf("Expected a full batch to be exported, got %d requests", len(collector.requests))
+	}
+	collector.mu.Unlock()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	if len(collector.requests) != 2 || collector.paths[0] != "/v1/logs" {
+		t.Fatalf("Expected 2 export requests to /v1/logs, got %v", collector.paths)
+	}
+	if n := len(collector.requests[0]["resourceLogs"].([]interface{})); n != 2 {
+		t.Errorf("Expected records grouped by resource, got %d groups", n)
+	}
+	if err := w.Write([]byte(`{"message":"late"}`)); err == nil {
+		t.Error("Expected error writing after Close")
+	}
+}
+
+func TestOTLPWriterErrors(t *testing.T) {
+	collector, srv := newOTLPCollector(t)
+	collector.status = http.StatusServiceUnavailable
+	w := NewOTLPWriter(srv.URL, WithHTTPBatch(10, time.Hour))
+
+	if err := w.Write([]byte("level=info msg=x")); err != errNotJSONRecord {
+		t.Errorf("Expected errNotJSONRecord, got %v", err)
+	}
+	if err := w.Write([]byte(`{"message":"x","level":"info"}`)); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if err := w.Flush(); err == nil {
+		t.Error("Expected error for failed export")
+	}
+}
@@ -0,0 +1,91 @@
+package xmuslogger
+
+import "io"
+
+// RecordEncoder converts a JSON record into another format for a single
+// output. record has no trailing newline; the result should not have one.
+type RecordEncoder interface {
+	EncodeRecord(dst, record []byte) ([]byte, error)
+}
+
+type OutputOption func(*output)
+
+// OutputLevel writes only records at or above min to the output.
+func OutputLevel(min Level) OutputOption {
+	return func(o *output) {
+		o.min = min
+	}
+}
+
+// OutputEncoder re-encodes records for the output with enc.
+func OutputEncoder(enc RecordEncoder) OutputOption {
+	return func(o *output) {
+		o.encoder = enc
+	}
+}
+
+// output wraps a writer added with options to AddOutput.
+type output struct {
+	w       io.Writer
+	min     Level
+	encoder RecordEncoder
+}
+
+// Write is used when the level is unknown, so the record is treated as info.
+func (o *output) Write(p []byte) (n int, err error) {
+	if InfoLevel < o.min {
+		return len(p), nil
+	}
+	return o.write(InfoLevel, p, false)
+}
+
+func (o *output) WriteLevel(level Level, p []byte) (n int, err error) {
+	if level < o.min {
+		return len(p), nil
+	}
+	return o.write(level, p, true)
+}
+
+func (o *output) write(level Level, p []byte, leveled bool) (n int, err error) {
+	data := p
+	if o.encoder != nil {
+		record := p
+		if len(record) > 0 && record[len(record)-1] == '\n' {
+			record = record[:len(record)-1]
+		}
+		data, err = o.encoder.EncodeRecord(nil, record)
+		if err != nil {
+			return 0, err
+		}
+		data = append(data, '\n')
+	}
+
+	if leveled {
+		_, err = writeLevel(o.w, level, data)
+	} else {
+		_, err = o.w.Write(data)
+	}
+	return len(p), err
+}
+
+func (o *output) Flush() error {
+	if f, ok := o.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// unwrapOutput returns the writer originally passed to AddOutput. The
+// wrappers nest in either order: Synchronized wraps outputs added earlier.
+func unwrapOutput(w io.Writer) io.Writer {
+	for {
+		switch v := w.(type) {
+		case *output:
+			w = v.w
+		case *syncWriter:
+			w = v.w
+		default:
+			return w
+		}
+	}
+}
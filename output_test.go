@@ -0,0 +1,104 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type upperEncoder struct{}
+
+func (upperEncoder) EncodeRecord(dst, record []byte) ([]byte, error) {
+	return append(dst, bytes.ToUpper(record)...), nil
+}
+
+func TestAddRemoveOutput(t *testing.T) {
+	var first, second bytes.Buffer
+	logger := NewWithOutput(&first)
+
+	logger.AddOutput(&second)
+	logger.Info().Msg("both")
+	logger.RemoveOutput(&first)
+	logger.Info().Msg("second only")
+
+	if strings.Count(first.String(), "\n") != 1 {
+		t.Errorf("Expected 1 line in first output, got %q", first.String())
+	}
+	if strings.Count(second.String(), "\n") != 2 {
+		t.Errorf("Expected 2 lines in second output, got %q", second.String())
+	}
+
+	outputs := logger.Outputs()
+	if len(outputs) != 1 || outputs[0] != &second {
+		t.Errorf("Expected Outputs() to return the remaining writer, got %v", outputs)
+	}
+}
+
+func TestAddOutputOptions(t *testing.T) {
+	var all, errs, encoded bytes.Buffer
+	logger := NewWithOutput(&all)
+	logger.AddOutput(&errs, OutputLevel(ErrorLevel))
+	logger.AddOutput(&encoded, OutputEncoder(upperEncoder{}))
+
+	logger.Info().Msg("info")
+	logger.Error().Msg("error")
+
+	if strings.Count(all.String(), "\n") != 2 {
+		t.Errorf("Expected 2 lines in unfiltered output, got %q", all.String())
+	}
+	if strings.Count(errs.String(), "\n") != 1 || !strings.Contains(errs.String(), `"level":"error"`) {
+		t.Errorf("Expected only the error in filtered output, got %q", errs.String())
+	}
+	if !strings.Contains(encoded.String(), `"LEVEL":"ERROR"`) || strings.Count(encoded.String(), "\n") != 2 {
+		t.Errorf("Expected encoded output, got %q", encoded.String())
+	}
+
+	logger.RemoveOutput(&errs)
+	if outputs := logger.Outputs(); len(outputs) != 2 || outputs[1] != &encoded {
+		t.Errorf("Expected options wrapper to be removed by its writer, got %v", outputs)
+	}
+}
+
+func TestOutputLevelUnleveledWrite(t *testing.T) {
+	var warn, debug bytes.Buffer
+	for _, o := range []*output{{w: &warn, min: WarnLevel}, {w: &debug, min: DebugLevel}} {
+		if n, err := o.Write([]byte("line\n")); n != 5 || err != nil {
+			t.Fatalf("Write() = %d, %v", n, err)
+		}
+	}
+	if warn.Len() != 0 {
+		t.Errorf("Expected unleveled writes to be filtered as info, got %q", warn.String())
+	}
+	if debug.String() != "line\n" {
+		t.Errorf("Expected unleveled writes above min to pass, got %q", debug.String())
+	}
+}
+
+func TestAddOutputConcurrent(t *testing.T) {
+	var base SafeBuffer
+	logger := NewWithOutput(&base)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			logger.Info().Int("i", i).Msg("concurrent")
+			logger.Print("stdlib")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			var extra SafeBuffer
+			logger.AddOutput(&extra)
+			logger.RemoveOutput(&extra)
+		}
+	}()
+	wg.Wait()
+
+	if lines := strings.Count(base.String(), "\n"); lines != 400 {
+		t.Errorf("Expected 400 lines in the base output, got %d", lines)
+	}
+}
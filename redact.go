@@ -0,0 +1,265 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type RedactMode int8
+
+const (
+	RedactFull    RedactMode = iota // Replace the whole value with "***"
+	RedactPartial                   // Keep a few leading/trailing characters
+	RedactHash                      // Replace with a salted SHA-256 fingerprint
+)
+
+const redactedValue = "***"
+
+// Detector finds sensitive substrings in values regardless of their key.
+// If the pattern has a capturing group only the first group is masked.
+type Detector struct {
+	Name     string
+	pattern  *regexp.Regexp
+	validate func(match string) bool
+}
+
+// NewDetector compiles pattern into a Detector. validate, if not nil, is
+// called on every match and can reject false positives.
+func NewDetector(name, pattern string, validate func(match string) bool) *Detector {
+	return &Detector{
+		Name:     name,
+		pattern:  regexp.MustCompile(pattern),
+		validate: validate,
+	}
+}
+
+// Built-in detectors
+var (
+	DetectJWT        = NewDetector("jwt", `eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`, nil)
+	DetectBearer     = NewDetector("bearer", `(?i)bearer\s+([A-Za-z0-9\-._~+/]+=*)`, nil)
+	DetectCreditCard = NewDetector("credit_card", `\b(?:\d[ -]?){12,18}\d\b`, luhnValid)
+	DetectEmail      = NewDetector("email", `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`, nil)
+)
+
+type Redactor struct {
+	keys      []string
+	detectors []*Detector
+	mode      RedactMode
+	salt      string
+}
+
+type RedactOption func(*Redactor)
+
+func NewRedactor(options ...RedactOption) *Redactor {
+	r := &Redactor{mode: RedactFull}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// RedactKeys masks fields whose key matches one of the patterns. Matching
+// is case-insensitive and supports path.Match globs such as "*_token".
+func RedactKeys(patterns ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, p := range patterns {
+			r.keys = append(r.keys, strings.ToLower(p))
+		}
+	}
+}
+
+// RedactValues masks substrings found by the detectors in any string value,
+// including messages.
+func RedactValues(detectors ...*Detector) RedactOption {
+	return func(r *Redactor) {
+		r.detectors = append(r.detectors, detectors...)
+	}
+}
+
+func RedactWithMode(mode RedactMode) RedactOption {
+	return func(r *Redactor) {
+		r.mode = mode
+	}
+}
+
+// RedactSalt sets the salt used by RedactHash.
+func RedactSalt(salt string) RedactOption {
+	return func(r *Redactor) {
+		r.salt = salt
+	}
+}
+
+// matchKey reports whether values stored under key must be masked.
+func (r *Redactor) matchKey(key string) bool {
+	if r == nil || len(r.keys) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, p := range r.keys {
+		if p == key {
+			return true
+		}
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// field returns val as it should be written under key.
+func (r *Redactor) field(key, val string) string {
+	if r == nil {
+		return val
+	}
+	if r.matchKey(key) {
+		return r.mask(val)
+	}
+	return r.text(val)
+}
+
+// text masks everything the detectors find in s.
+func (r *Redactor) text(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, d := range r.detectors {
+		s = r.replace(d, s)
+	}
+	return s
+}
+
+// json masks a JSON value: values under matching object keys at any depth,
+// and whatever the detectors find in strings. Field order is kept.
+func (r *Redactor) json(raw []byte) []byte {
+	if r == nil || (len(r.keys) == 0 && len(r.detectors) == 0) {
+		return raw
+	}
+	return r.appendJSON(nil, bytes.TrimSpace(raw))
+}
+
+// appendJSON appends the masked form of raw to dst. Should raw not decode,
+// it is appended as is, keeping what dst already holds.
+func (r *Redactor) appendJSON(dst, raw []byte) []byte {
+	if len(raw) == 0 {
+		return dst
+	}
+	switch raw[0] {
+	case '{', '[':
+		start := len(dst)
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.Token() // Opening delimiter
+		dst = append(dst, raw[0])
+		for first := true; dec.More(); first = false {
+			if !first {
+				dst = append(dst, ',')
+			}
+			var key string
+			if raw[0] == '{' {
+				tok, err := dec.Token()
+				if err != nil {
+					return append(dst[:start], raw...)
+				}
+				key, _ = tok.(string)
+				dst = appendQuoted(dst, key)
+				dst = append(dst, ':')
+			}
+			var v json.RawMessage
+			if err := dec.Decode(&v); err != nil {
+				return append(dst[:start], raw...)
+			}
+			if raw[0] == '{' && r.matchKey(key) {
+				s := string(v)
+				if len(v) > 0 && v[0] == '"' {
+					json.Unmarshal(v, &s)
+				}
+				dst = appendQuoted(dst, r.mask(s))
+			} else {
+				dst = r.appendJSON(dst, v)
+			}
+		}
+		if raw[0] == '{' {
+			return append(dst, '}')
+		}
+		return append(dst, ']')
+	case '"':
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			if masked := r.text(s); masked != s {
+				return appendQuoted(dst, masked)
+			}
+		}
+	}
+	return append(dst, raw...)
+}
+
+func (r *Redactor) replace(d *Detector, s string) string {
+	matches := d.pattern.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if d.validate != nil && !d.validate(s[start:end]) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(r.mask(s[start:end]))
+		last = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (r *Redactor) mask(val string) string {
+	switch r.mode {
+	case RedactPartial:
+		n := utf8.RuneCountInString(val)
+		keep := n / 4
+		if keep > 4 {
+			keep = 4
+		}
+		if keep == 0 {
+			return redactedValue
+		}
+		runes := []rune(val)
+		return string(runes[:keep]) + redactedValue + string(runes[n-keep:])
+	case RedactHash:
+		return Secret(val).Fingerprint(r.salt)
+	default:
+		return redactedValue
+	}
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -1,28 +1,86 @@
 package xmuslogger
 
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPRemoteWriter posts records to an endpoint in batches, configured
+// with WithHTTPBatch. Records are framed as their encoder separates them,
+// e.g. newline-delimited JSON, or a CBOR sequence for CBOREncoder.
 type HTTPRemoteWriter struct {
-	endpoint string
-	headers  map[string]string
-	// Implementation details would go here
+	endpoint      string
+	headers       map[string]string
+	client        *http.Client
+	batchSize     int
+	flushInterval time.Duration
+	enc           Encoder
+	batch         *batcher[[]byte]
 }
 
 type HTTPOption func(*HTTPRemoteWriter)
 
 func NewHTTPRemoteWriter(endpoint string, options ...HTTPOption) *HTTPRemoteWriter {
 	w := &HTTPRemoteWriter{
-		endpoint: endpoint,
-		headers:  make(map[string]string),
+		endpoint:      endpoint,
+		headers:       make(map[string]string),
+		client:        &http.Client{Timeout: 10 * time.Second},
+		batchSize:     100,
+		flushInterval: time.Second,
+		enc:           JSONEncoder{},
 	}
 	for _, opt := range options {
 		opt(w)
 	}
+	w.batch = newBatcher(w.batchSize, w.flushInterval, w.send)
 	return w
 }
 
-func (h *HTTPRemoteWriter) Write(data []byte) error      { return nil } // TODO: Implement
-func (h *HTTPRemoteWriter) WriteAsync(data []byte) error { return nil } // TODO: Implement
-func (h *HTTPRemoteWriter) Flush() error                 { return nil }
-func (h *HTTPRemoteWriter) Close() error                 { return nil }
+func (h *HTTPRemoteWriter) Write(data []byte) error {
+	return h.batch.add(append([]byte(nil), data...), false)
+}
+
+func (h *HTTPRemoteWriter) WriteAsync(data []byte) error {
+	return h.batch.add(append([]byte(nil), data...), true)
+}
+
+func (h *HTTPRemoteWriter) Flush() error { return h.batch.flush() }
+func (h *HTTPRemoteWriter) Close() error { return h.batch.close() }
+
+func (h *HTTPRemoteWriter) send(batch [][]byte) error {
+	var body []byte
+	for _, record := range batch {
+		body = h.enc.AppendLineBreak(append(body, record...))
+	}
+
+	resp, err := h.post(h.endpoint, contentType(h.enc), body)
+	if err != nil {
+		return err
+	}
+	defer drainBody(resp)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("xmuslogger: HTTP remote write failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// contentType returns the media type of a batch of records written by enc.
+func contentType(enc Encoder) string {
+	switch enc.(type) {
+	case CBOREncoder:
+		return "application/cbor-seq"
+	case LogfmtEncoder:
+		return "text/plain; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
 
 func WithHTTPAuth(token string) HTTPOption {
 	return func(w *HTTPRemoteWriter) {
@@ -37,3 +95,165 @@ func WithHTTPHeaders(headers map[string]string) HTTPOption {
 		}
 	}
 }
+
+func WithHTTPBasicAuth(username, password string) HTTPOption {
+	return func(w *HTTPRemoteWriter) {
+		auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		w.headers["Authorization"] = "Basic " + auth
+	}
+}
+
+// WithHTTPAPIKey authenticates with an Elasticsearch-style API key, the
+// base64 encoded id:api_key pair.
+func WithHTTPAPIKey(key string) HTTPOption {
+	return func(w *HTTPRemoteWriter) {
+		w.headers["Authorization"] = "ApiKey " + key
+	}
+}
+
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(w *HTTPRemoteWriter) {
+		w.client = client
+	}
+}
+
+// WithHTTPEncoder frames records as written by enc, JSONEncoder{} by
+// default. Logger.RemoteHTTP sets it to the logger's encoder.
+func WithHTTPEncoder(enc Encoder) HTTPOption {
+	return func(w *HTTPRemoteWriter) {
+		w.enc = enc
+	}
+}
+
+// WithHTTPBatch sends records in batches of up to size, at least once per
+// interval, 100 records and one second by default. Batching writers such
+// as OTLPWriter use it too.
+func WithHTTPBatch(size int, interval time.Duration) HTTPOption {
+	return func(w *HTTPRemoteWriter) {
+		w.batchSize = size
+		w.flushInterval = interval
+	}
+}
+
+// post sends body to url with the configured headers. The caller must close
+// the response body.
+func (h *HTTPRemoteWriter) post(url, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	return h.client.Do(req)
+}
+
+var errNotJSONRecord = errors.New("xmuslogger: remote writer requires JSON records")
+
+// jsonRecord returns data as a JSON record. Records written by CBOREncoder
+// are transcoded, so writers that need JSON work with it too.
+func jsonRecord(data []byte) ([]byte, error) {
+	if len(data) > 0 && data[0] == cborMapStart {
+		d := cborDecoder{r: bytes.NewReader(data)}
+		rec, err := d.item(nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		data = rec
+	}
+	if len(data) < 2 || data[0] != '{' || data[len(data)-1] != '}' {
+		return nil, errNotJSONRecord
+	}
+	return data, nil
+}
+
+// jsonFields returns the fields of a JSON record, without the braces.
+func jsonFields(data []byte) ([]byte, error) {
+	data, err := jsonRecord(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[1 : len(data)-1], nil
+}
+
+// jsonContext returns a logger context as JSON fields, each followed by a
+// comma as JSONEncoder writes them, transcoding CBOREncoder fields. A
+// context that cannot be transcoded is dropped.
+func jsonContext(context []byte) []byte {
+	if len(context) == 0 || context[0] == '"' {
+		return context
+	}
+	r := bytes.NewReader(context)
+	d := cborDecoder{r: r}
+	var dst []byte
+	for r.Len() > 0 {
+		var err error
+		if dst, err = d.item(dst, 1); err != nil {
+			return nil
+		}
+		dst = append(dst, ':')
+		if dst, err = d.item(dst, 1); err != nil {
+			return nil
+		}
+		dst = append(dst, ',')
+	}
+	return dst
+}
+
+// recordTime returns the "time" field of a JSON record's fields, or the
+// current time if it has none.
+func recordTime(fields []byte) time.Time {
+	var s string
+	if v := fieldValue(fields, "time"); v != nil && json.Unmarshal(v, &s) == nil {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// retryBackoff calls try until it succeeds, fails for good or has been
+// retried retries times, returning its last error. Retries wait for the
+// server's Retry-After seconds if set, else a backoff doubling from min;
+// waits are capped at max.
+func retryBackoff(retries int, min, max time.Duration, try func() (retry bool, retryAfter string, err error)) error {
+	backoff := min
+	for attempt := 0; ; attempt++ {
+		retry, retryAfter, err := try()
+		if !retry || attempt >= retries {
+			return err
+		}
+
+		wait := backoff
+		if secs, err := strconv.Atoi(retryAfter); err == nil {
+			wait = time.Duration(secs) * time.Second
+		}
+		if wait > max {
+			wait = max
+		}
+		time.Sleep(wait)
+		if backoff *= 2; backoff > max {
+			backoff = max
+		}
+	}
+}
+
+// drainBody lets the connection be reused.
+func drainBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+// contextWriter is implemented by remote writers that keep the logger's
+// context fields apart from the event's own, e.g. as OpenTelemetry resource
+// attributes. context is a prefix of the record's fields.
+type contextWriter interface {
+	writeContext(context, record []byte, async bool) error
+}
+
+// levelRemoteWriter is implemented by remote writers that filter by level,
+// such as MultiRemoteWriter. It takes precedence over contextWriter.
+type levelRemoteWriter interface {
+	writeRemoteLevel(level Level, context, record []byte) error
+}
@@ -0,0 +1,93 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type httpRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newHTTPServer(t *testing.T, status int) (*httptest.Server, func() []httpRequest) {
+	var mu sync.Mutex
+	var requests []httpRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, httpRequest{header: r.Header.Clone(), body: body})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []httpRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func TestHTTPRemoteWriterBatches(t *testing.T) {
+	srv, requests := newHTTPServer(t, http.StatusOK)
+	logger := NewWithOutput(io.Discard).RemoteHTTP(srv.URL, WithHTTPAuth("token"), WithHTTPBatch(2, time.Hour))
+	logger.Info().Msg("one")
+	logger.Info().Msg("two")
+	logger.Info().Msg("three")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	reqs := requests()
+	if len(reqs) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(reqs))
+	}
+	lines := strings.Split(strings.TrimSuffix(string(reqs[0].body), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"message":"one"`) || !strings.Contains(lines[1], `"message":"two"`) {
+		t.Errorf("Expected the first two records in the first batch, got %q", reqs[0].body)
+	}
+	if !strings.Contains(string(reqs[1].body), `"message":"three"`) {
+		t.Errorf("Expected the last record on Close, got %q", reqs[1].body)
+	}
+	if h := reqs[0].header; h.Get("Authorization") != "Bearer token" || h.Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("Unexpected headers %v", h)
+	}
+}
+
+func TestHTTPRemoteWriterCBOR(t *testing.T) {
+	srv, requests := newHTTPServer(t, http.StatusOK)
+	logger := NewWithOutput(io.Discard).Encoder(CBOREncoder{}).RemoteHTTP(srv.URL)
+	logger.Info().Msg("one")
+	logger.Info().Msg("two")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	reqs := requests()
+	if len(reqs) != 1 || reqs[0].header.Get("Content-Type") != "application/cbor-seq" {
+		t.Fatalf("Expected one CBOR sequence request, got %v", reqs)
+	}
+	var out bytes.Buffer
+	if err := CBORToJSON(&out, bytes.NewReader(reqs[0].body)); err != nil {
+		t.Fatalf("Body is not a CBOR sequence: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 {
+		t.Errorf("Expected 2 records, got %q", out.String())
+	}
+}
+
+func TestHTTPRemoteWriterStatusError(t *testing.T) {
+	srv, _ := newHTTPServer(t, http.StatusServiceUnavailable)
+	w := NewHTTPRemoteWriter(srv.URL)
+	defer w.Close()
+	w.Write([]byte(`{"message":"lost"}`))
+	if err := w.Flush(); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Expected the status in the error, got %v", err)
+	}
+}
@@ -0,0 +1,535 @@
+package xmuslogger
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	RotateHourly = time.Hour
+	RotateDaily  = 24 * time.Hour
+)
+
+const defaultRotateTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFileWriter is an io.Writer that writes to a file and rotates it
+// by size, by time or both. Rotated files are named after the original with
+// a timestamp inserted before the extension, e.g. app-2026-10-18T19-00-00.000.log.
+// Compression and pruning of rotated files run in a background goroutine.
+type RotatingFileWriter struct {
+	filename     string
+	maxSize      int64
+	interval     time.Duration
+	maxBackups   int
+	maxAge       time.Duration
+	maxTotalSize int64
+	compressor   Compressor
+	symlink      string
+	timeFormat   string
+	reopenHUP    bool
+	onError      func(error)
+
+	mu       sync.Mutex
+	file     *os.File
+	current  string // Path of the file being written
+	size     int64
+	openedAt time.Time
+	rotateAt time.Time
+	closed   bool
+	signals  chan os.Signal
+	done     chan struct{}
+	maintain chan struct{} // Wakes the background worker
+	pending  bool          // A maintenance pass is queued or running
+	idle     *sync.Cond    // Signalled when pending is cleared
+	wg       sync.WaitGroup
+}
+
+type RotateOption func(*RotatingFileWriter)
+
+func NewRotatingFileWriter(filename string, options ...RotateOption) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{
+		filename:   filename,
+		timeFormat: defaultRotateTimeFormat,
+	}
+	w.idle = sync.NewCond(&w.mu)
+	for _, opt := range options {
+		opt(w)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.open(time.Now()); err != nil {
+		return nil, err
+	}
+
+	if w.compressor != nil || w.maxBackups > 0 || w.maxAge > 0 || w.maxTotalSize > 0 {
+		w.maintain = make(chan struct{}, 1)
+		w.wg.Add(1)
+		go w.runMaintenance()
+		w.requestMaintenance() // Handle backups left by a previous run
+	}
+	if w.reopenHUP {
+		w.signals = make(chan os.Signal, 1)
+		w.done = make(chan struct{})
+		signal.Notify(w.signals, syscall.SIGHUP)
+		go w.watchSignals()
+	}
+	return w, nil
+}
+
+// RotateMaxSize rotates once the file would grow beyond size bytes.
+func RotateMaxSize(size int64) RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.maxSize = size
+	}
+}
+
+// RotateEvery rotates on every interval boundary, e.g. RotateHourly or
+// RotateDaily. Boundaries are computed in UTC.
+func RotateEvery(interval time.Duration) RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.interval = interval
+	}
+}
+
+// RotateMaxBackups keeps at most n rotated files.
+func RotateMaxBackups(n int) RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.maxBackups = n
+	}
+}
+
+// RotateMaxAge removes rotated files older than age.
+func RotateMaxAge(age time.Duration) RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.maxAge = age
+	}
+}
+
+// RotateMaxTotalSize removes the oldest rotated files once together they
+// take more than size bytes on disk.
+func RotateMaxTotalSize(size int64) RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.maxTotalSize = size
+	}
+}
+
+// RotateErrorHandler handles compression and pruning failures instead of
+// the handler set with SetErrorHandler.
+func RotateErrorHandler(fn func(err error)) RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.onError = fn
+	}
+}
+
+// RotateCompress compresses rotated files with c, e.g. GzipCompressor{}.
+func RotateCompress(c Compressor) RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.compressor = c
+	}
+}
+
+// RotateSymlink writes every file under its timestamped name and keeps a
+// symlink at path pointing to the current one.
+func RotateSymlink(path string) RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.symlink = path
+	}
+}
+
+// RotateTimeFormat sets the time layout used in rotated file names.
+func RotateTimeFormat(layout string) RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.timeFormat = layout
+	}
+}
+
+// RotateReopenOnSIGHUP reopens the file when the process receives SIGHUP,
+// for use with external tools such as logrotate.
+func RotateReopenOnSIGHUP() RotateOption {
+	return func(w *RotatingFileWriter) {
+		w.reopenHUP = true
+	}
+}
+
+func (w *RotatingFileWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	now := time.Now()
+	if w.file == nil {
+		if err := w.open(now); err != nil {
+			return 0, err
+		}
+	}
+	if w.shouldRotate(now, len(p)) {
+		if err := w.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file and starts a new one.
+func (w *RotatingFileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate(time.Now())
+}
+
+// Reopen closes and reopens the current file without rotating, picking up
+// a new file if the old one was moved away.
+func (w *RotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil // Closed; the next Write opens a fresh file
+	}
+	w.file.Close()
+	w.file = nil
+	return w.openFile(w.current)
+}
+
+// Flush waits until rotated files have been compressed and pruned.
+func (w *RotatingFileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for w.pending {
+		w.idle.Wait()
+	}
+	return nil
+}
+
+// Close closes the current file and waits for in-flight compression.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+
+	if w.done != nil {
+		signal.Stop(w.signals)
+		close(w.done)
+	}
+	if w.maintain != nil {
+		close(w.maintain)
+	}
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	return err
+}
+
+func (w *RotatingFileWriter) watchSignals() {
+	for {
+		select {
+		case <-w.signals:
+			w.Reopen()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *RotatingFileWriter) shouldRotate(now time.Time, n int) bool {
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(n) > w.maxSize {
+		return true
+	}
+	return w.interval > 0 && !now.Before(w.rotateAt)
+}
+
+func (w *RotatingFileWriter) rotate(now time.Time) error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+
+	if w.symlink == "" {
+		if err := os.Rename(w.filename, w.unusedBackupName(w.openedAt)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := w.open(now); err != nil {
+		return err
+	}
+	if w.maintain != nil {
+		w.requestMaintenance()
+	}
+	return nil
+}
+
+// requestMaintenance wakes the background worker; w.mu must be held.
+func (w *RotatingFileWriter) requestMaintenance() {
+	w.pending = true
+	select {
+	case w.maintain <- struct{}{}:
+	default: // A pass is already queued
+	}
+}
+
+// open starts a new current file; w.mu must be held.
+func (w *RotatingFileWriter) open(now time.Time) error {
+	name := w.filename
+	if w.symlink != "" {
+		name = w.backupName(now)
+	}
+	if err := w.openFile(name); err != nil {
+		return err
+	}
+
+	w.openedAt = now
+	if w.interval > 0 {
+		w.rotateAt = now.Truncate(w.interval).Add(w.interval)
+	}
+	if w.symlink != "" {
+		tmp := w.symlink + ".tmp"
+		os.Remove(tmp)
+		if err := os.Symlink(name, tmp); err != nil {
+			return err
+		}
+		return os.Rename(tmp, w.symlink)
+	}
+	return nil
+}
+
+func (w *RotatingFileWriter) openFile(name string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.current = name
+	w.size = info.Size()
+	return nil
+}
+
+func (w *RotatingFileWriter) backupName(t time.Time) string {
+	ext := filepath.Ext(w.filename)
+	base := strings.TrimSuffix(w.filename, ext)
+	return base + "-" + t.Format(w.timeFormat) + ext
+}
+
+// unusedBackupName avoids overwriting an earlier backup when rotations
+// happen faster than the time format's resolution.
+func (w *RotatingFileWriter) unusedBackupName(t time.Time) string {
+	name := w.backupName(t)
+	step := time.Millisecond
+	for {
+		if _, err := os.Lstat(name); os.IsNotExist(err) {
+			return name
+		}
+		next := w.backupName(t.Add(step))
+		for next == name {
+			step *= 2
+			next = w.backupName(t.Add(step))
+		}
+		t, name = t.Add(step), next
+	}
+}
+
+type rotatedFile struct {
+	path       string
+	time       time.Time
+	size       int64
+	compressed bool
+}
+
+// backups lists rotated files, newest first, excluding current.
+func (w *RotatingFileWriter) backups(current string) []rotatedFile {
+	ext := filepath.Ext(w.filename)
+	prefix := filepath.Base(strings.TrimSuffix(w.filename, ext)) + "-"
+	dir := filepath.Dir(w.filename)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var files []rotatedFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if path == current {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, prefix)
+		compressed := false
+		if w.compressor != nil && strings.HasSuffix(stamp, w.compressor.Extension()) {
+			stamp = strings.TrimSuffix(stamp, w.compressor.Extension())
+			compressed = true
+		}
+		stamp = strings.TrimSuffix(stamp, ext)
+		t, err := time.ParseInLocation(w.timeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, rotatedFile{path: path, time: t, size: info.Size(), compressed: compressed})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].time.After(files[j].time) })
+	return files
+}
+
+func (w *RotatingFileWriter) runMaintenance() {
+	defer w.wg.Done()
+	for range w.maintain {
+		w.mu.Lock()
+		current := w.current
+		w.mu.Unlock()
+
+		files := w.backups(current)
+		if w.compressor != nil {
+			for i, f := range files {
+				// A rotation since the snapshot may have made f current
+				if f.compressed || w.isCurrent(f.path) {
+					continue
+				}
+				if err := w.compress(&files[i]); err != nil {
+					w.handleError(err)
+				}
+			}
+		}
+		w.prune(files)
+
+		w.mu.Lock()
+		if len(w.maintain) == 0 {
+			w.pending = false
+			w.idle.Broadcast()
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (w *RotatingFileWriter) handleError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+		return
+	}
+	handleError(err)
+}
+
+// compress replaces f with its compressed copy.
+func (w *RotatingFileWriter) compress(f *rotatedFile) error {
+	dst := f.path + w.compressor.Extension()
+	if err := compressFile(w.compressor, f.path, dst); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	if removed, err := w.removeBackup(f.path); !removed {
+		os.Remove(dst)
+		return err
+	}
+
+	f.path = dst
+	f.compressed = true
+	if info, err := os.Stat(dst); err == nil {
+		f.size = info.Size()
+	}
+	return nil
+}
+
+// isCurrent reports whether path is the file being written.
+func (w *RotatingFileWriter) isCurrent(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return path == w.current
+}
+
+// removeBackup removes path unless it has become the file being written.
+func (w *RotatingFileWriter) removeBackup(path string) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if path == w.current {
+		return false, nil
+	}
+	if err := os.Remove(path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func compressFile(c Compressor, src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	cw, err := c.NewWriter(out)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	if _, err := io.Copy(cw, in); err != nil {
+		cw.Close()
+		out.Close()
+		return err
+	}
+	if err := cw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// prune removes rotated files beyond the count, age or disk budget limits.
+func (w *RotatingFileWriter) prune(files []rotatedFile) {
+	cutoff := time.Now().Add(-w.maxAge)
+	var total int64
+	for i, f := range files {
+		total += f.size
+		if (w.maxBackups > 0 && i >= w.maxBackups) ||
+			(w.maxAge > 0 && f.time.Before(cutoff)) ||
+			(w.maxTotalSize > 0 && total > w.maxTotalSize) {
+			if _, err := w.removeBackup(f.path); err != nil && !os.IsNotExist(err) {
+				w.handleError(err)
+			}
+		}
+	}
+}
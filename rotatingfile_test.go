@@ -0,0 +1,407 @@
+package xmuslogger
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func listLogFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestRotatingFileWriterSize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateMaxSize(100))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	logger := New().Output(w)
+	for i := 0; i < 10; i++ {
+		logger.Info().Int("i", i).Msg("rotation test")
+		time.Sleep(2 * time.Millisecond) // Distinct backup timestamps
+	}
+
+	files := listLogFiles(t, dir)
+	if len(files) < 5 {
+		t.Fatalf("Expected several rotated files, got %v", files)
+	}
+	for _, name := range files {
+		info, _ := os.Stat(filepath.Join(dir, name))
+		if info.Size() > 100 {
+			t.Errorf("File %s exceeds max size: %d", name, info.Size())
+		}
+	}
+}
+
+func TestRotatingFileWriterInterval(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateEvery(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("first\n"))
+	time.Sleep(30 * time.Millisecond)
+	w.Write([]byte("second\n"))
+
+	files := listLogFiles(t, dir)
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files after interval, got %v", files)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	if string(data) != "second\n" {
+		t.Errorf("Expected current file to hold the second write, got %q", data)
+	}
+}
+
+func TestRotatingFileWriterMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateMaxBackups(2))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	for i := 0; i < 5; i++ {
+		w.Write([]byte("line\n"))
+		time.Sleep(2 * time.Millisecond)
+		if err := w.Rotate(); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+	}
+	w.Close() // Waits for background pruning
+
+	if files := listLogFiles(t, dir); len(files) != 3 {
+		t.Errorf("Expected current file plus 2 backups, got %v", files)
+	}
+}
+
+func TestRotatingFileWriterSymlink(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "current.log")
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateSymlink(link))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("before\n"))
+	time.Sleep(2 * time.Millisecond)
+	w.Rotate()
+	w.Write([]byte("after\n"))
+
+	target, err := os.Readlink(link)
+	if err != nil {
+		t.Fatalf("Readlink failed: %v", err)
+	}
+	if !strings.HasPrefix(filepath.Base(target), "app-") {
+		t.Errorf("Expected symlink to a timestamped file, got %s", target)
+	}
+	data, _ := os.ReadFile(link)
+	if string(data) != "after\n" {
+		t.Errorf("Expected symlink to point at the current file, got %q", data)
+	}
+}
+
+func TestRotatingFileWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := NewRotatingFileWriter(name)
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("old\n"))
+	os.Rename(name, name+".1") // What logrotate does before sending SIGHUP
+	if err := w.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	w.Write([]byte("new\n"))
+
+	data, _ := os.ReadFile(name)
+	if string(data) != "new\n" {
+		t.Errorf("Expected reopened file to hold only new writes, got %q", data)
+	}
+}
+
+func TestRotatingFileWriterConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateMaxSize(4096))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	logger := New().Output(w)
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				logger.Info().Int("goroutine", g).Int("i", i).Msg("concurrent")
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	lines := 0
+	for _, name := range listLogFiles(t, dir) {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			if _, err := parseLogLine(line); err != nil {
+				t.Fatalf("Corrupted line in %s: %q", name, line)
+			}
+			lines++
+		}
+	}
+	if lines != 500 {
+		t.Errorf("Expected 500 lines across files, got %d", lines)
+	}
+}
+
+func TestRotatingFileWriterCompress(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateCompress(GzipCompressor{}))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+
+	w.Write([]byte("compress me\n"))
+	w.Rotate()
+	w.Write([]byte("current\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var compressed []string
+	for _, name := range listLogFiles(t, dir) {
+		if strings.HasSuffix(name, ".gz") {
+			compressed = append(compressed, name)
+		}
+	}
+	if len(compressed) != 1 {
+		t.Fatalf("Expected 1 compressed backup, got %v", listLogFiles(t, dir))
+	}
+
+	f, _ := os.Open(filepath.Join(dir, compressed[0]))
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader failed: %v", err)
+	}
+	data, _ := io.ReadAll(zr)
+	if string(data) != "compress me\n" {
+		t.Errorf("Unexpected decompressed content %q", data)
+	}
+	if current, _ := os.ReadFile(filepath.Join(dir, "app.log")); string(current) != "current\n" {
+		t.Errorf("Current file should stay uncompressed, got %q", current)
+	}
+}
+
+func TestRotatingFileWriterCompressZstd(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateCompress(ZstdCompressor{Level: 3}))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+
+	w.Write([]byte("compress me\n"))
+	w.Rotate()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var compressed string
+	for _, name := range listLogFiles(t, dir) {
+		if strings.HasSuffix(name, ".zst") {
+			compressed = name
+		}
+	}
+	if compressed == "" {
+		t.Fatalf("Expected a .zst backup, got %v", listLogFiles(t, dir))
+	}
+	f, _ := os.Open(filepath.Join(dir, compressed))
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		t.Fatalf("zstd.NewReader failed: %v", err)
+	}
+	defer zr.Close()
+	if data, _ := io.ReadAll(zr); string(data) != "compress me\n" {
+		t.Errorf("Unexpected decompressed content %q", data)
+	}
+}
+
+func TestRotatingFileWriterMaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateMaxTotalSize(25))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		w.Write([]byte("0123456789\n"))
+		time.Sleep(2 * time.Millisecond)
+		w.Rotate()
+	}
+	w.Close()
+
+	if files := listLogFiles(t, dir); len(files) != 3 {
+		t.Errorf("Expected current file plus 2 backups within budget, got %v", files)
+	}
+}
+
+type failingCompressor struct{}
+
+func (failingCompressor) Extension() string { return ".bad" }
+func (failingCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nil, errors.New("compressor unavailable")
+}
+
+func TestRotateErrorHandler(t *testing.T) {
+	errs := make(chan error, 4)
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"),
+		RotateCompress(failingCompressor{}),
+		RotateErrorHandler(func(err error) { errs <- err }),
+	)
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	w.Write([]byte("data\n"))
+	w.Rotate()
+	w.Close()
+
+	select {
+	case err := <-errs:
+		if err.Error() != "compressor unavailable" {
+			t.Errorf("Unexpected error %v", err)
+		}
+	default:
+		t.Error("Expected the compression error to reach the writer's handler")
+	}
+}
+
+func TestRotatingFileWriterCompressError(t *testing.T) {
+	var mu sync.Mutex
+	var handled []error
+	SetErrorHandler(func(err error) {
+		mu.Lock()
+		handled = append(handled, err)
+		mu.Unlock()
+	})
+	defer SetErrorHandler(nil)
+
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateCompress(failingCompressor{}))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	w.Write([]byte("line\n"))
+	w.Rotate()
+	w.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 1 || handled[0].Error() != "compressor unavailable" {
+		t.Errorf("Expected compression error to reach the error handler, got %v", handled)
+	}
+	for _, name := range listLogFiles(t, dir) {
+		if strings.HasSuffix(name, ".bad") {
+			t.Errorf("Partial compressed file %s should be removed", name)
+		}
+	}
+	if _, err := w.Write([]byte("late\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected os.ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestLoggerCloseWaitsForCompression(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), RotateCompress(GzipCompressor{}), RotateMaxBackups(5))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	defer w.Close()
+	logger := New().Output(w)
+
+	for i := 0; i < 3; i++ {
+		logger.Info().Int("segment", i).Msg("rotate me")
+		time.Sleep(2 * time.Millisecond)
+		w.Rotate()
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	files := listLogFiles(t, dir)
+	compressed := 0
+	for _, name := range files {
+		if strings.HasSuffix(name, ".gz") {
+			compressed++
+		} else if name != "app.log" {
+			t.Errorf("Expected %s to be compressed once Logger.Close returned", name)
+		}
+	}
+	if compressed != 3 {
+		t.Errorf("Expected 3 compressed backups, got %v", files)
+	}
+}
+
+func TestRotatingFileWriterMaintenanceSkipsCurrent(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "current.log")
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"),
+		RotateSymlink(link), RotateCompress(GzipCompressor{}), RotateMaxTotalSize(1))
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter failed: %v", err)
+	}
+	defer w.Close()
+	w.Write([]byte("live\n"))
+	w.Flush()
+
+	// A listing taken with a stale snapshot of the current file, as when a
+	// rotation lands between the snapshot and the directory read
+	files := w.backups("")
+	if len(files) != 1 || !w.isCurrent(files[0].path) {
+		t.Fatalf("Expected the live file in the stale listing, got %v", files)
+	}
+	if err := w.compress(&files[0]); err != nil {
+		t.Fatalf("compress returned error: %v", err)
+	}
+	w.prune(w.backups(""))
+
+	w.Write([]byte("still live\n"))
+	if data, _ := os.ReadFile(link); string(data) != "live\nstill live\n" {
+		t.Errorf("Expected the live file to survive maintenance, got %q", data)
+	}
+	if files := listLogFiles(t, dir); len(files) != 2 {
+		t.Errorf("Expected only the live file and its symlink, got %v", files)
+	}
+}
@@ -0,0 +1,123 @@
+package xmuslogger
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler decides whether an event at the given level is written. It is
+// consulted before the event is built, so sampled-out events cost the same
+// as disabled levels.
+type Sampler interface {
+	Sample(level Level) bool
+}
+
+// EverySampler passes one event out of every N.
+type EverySampler struct {
+	N       uint32
+	counter atomic.Uint32
+}
+
+func (s *EverySampler) Sample(level Level) bool {
+	if s.N <= 1 {
+		return true
+	}
+	return s.counter.Add(1)%s.N == 1
+}
+
+// RandomSampler passes each event with the given probability.
+type RandomSampler struct {
+	Probability float64
+}
+
+func (s *RandomSampler) Sample(level Level) bool {
+	if s.Probability <= 0 {
+		return false
+	}
+	return s.Probability >= 1 || rand.Float64() < s.Probability
+}
+
+// BurstSampler passes up to Burst events per Period and hands the rest to
+// NextSampler. With no NextSampler, events over the burst are dropped.
+type BurstSampler struct {
+	Burst       uint32
+	Period      time.Duration
+	NextSampler Sampler
+
+	counter atomic.Uint32
+	resetAt atomic.Int64
+}
+
+func (s *BurstSampler) Sample(level Level) bool {
+	if s.Burst > 0 && s.inc() <= s.Burst {
+		return true
+	}
+	if s.NextSampler == nil {
+		return false
+	}
+	return s.NextSampler.Sample(level)
+}
+
+func (s *BurstSampler) inc() uint32 {
+	now := time.Now().UnixNano()
+	resetAt := s.resetAt.Load()
+	if now > resetAt && s.resetAt.CompareAndSwap(resetAt, now+int64(s.Period)) {
+		s.counter.Store(1)
+		return 1
+	}
+	return s.counter.Add(1)
+}
+
+// LevelSampler applies a different sampler per level. Levels without a
+// sampler are not sampled.
+type LevelSampler struct {
+	TraceSampler Sampler
+	DebugSampler Sampler
+	InfoSampler  Sampler
+	WarnSampler  Sampler
+	ErrorSampler Sampler
+}
+
+func (s *LevelSampler) Sample(level Level) bool {
+	var sampler Sampler
+	switch level {
+	case TraceLevel:
+		sampler = s.TraceSampler
+	case DebugLevel:
+		sampler = s.DebugSampler
+	case InfoLevel:
+		sampler = s.InfoSampler
+	case WarnLevel:
+		sampler = s.WarnSampler
+	case ErrorLevel:
+		sampler = s.ErrorSampler
+	}
+	return sampler == nil || sampler.Sample(level)
+}
+
+type sampling struct {
+	sampler Sampler
+	count   bool
+	dropped [8]atomic.Uint64
+}
+
+type SampleOption func(*sampling)
+
+// WithSampledCount adds a "sampled" field to written events holding the
+// number of events dropped at that level since the previous one.
+func WithSampledCount() SampleOption {
+	return func(s *sampling) {
+		s.count = true
+	}
+}
+
+func (s *sampling) sample(level Level) bool {
+	if s.sampler.Sample(level) {
+		return true
+	}
+	if s.count {
+		s.dropped[level].Add(1)
+	}
+	return false
+}
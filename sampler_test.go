@@ -0,0 +1,121 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEverySampler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(&buf).Sample(&EverySampler{N: 10})
+
+	for i := 0; i < 100; i++ {
+		logger.Info().Int("i", i).Msg("sampled")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 10 {
+		t.Errorf("Expected 10 log lines, got %d", len(lines))
+	}
+}
+
+func TestRandomSampler(t *testing.T) {
+	never := &RandomSampler{Probability: 0}
+	always := &RandomSampler{Probability: 1}
+	for i := 0; i < 100; i++ {
+		if never.Sample(InfoLevel) {
+			t.Fatal("Probability 0 should never sample")
+		}
+		if !always.Sample(InfoLevel) {
+			t.Fatal("Probability 1 should always sample")
+		}
+	}
+
+	half := &RandomSampler{Probability: 0.5}
+	passed := 0
+	for i := 0; i < 10000; i++ {
+		if half.Sample(InfoLevel) {
+			passed++
+		}
+	}
+	if passed < 4000 || passed > 6000 {
+		t.Errorf("Expected roughly half to pass, got %d/10000", passed)
+	}
+}
+
+func TestBurstSampler(t *testing.T) {
+	s := &BurstSampler{Burst: 5, Period: time.Hour, NextSampler: &EverySampler{N: 10}}
+
+	passed := 0
+	for i := 0; i < 105; i++ {
+		if s.Sample(InfoLevel) {
+			passed++
+		}
+	}
+	if passed != 15 {
+		t.Errorf("Expected 5 burst + 10 sampled events, got %d", passed)
+	}
+
+	s = &BurstSampler{Burst: 2, Period: 10 * time.Millisecond}
+	s.Sample(InfoLevel)
+	s.Sample(InfoLevel)
+	if s.Sample(InfoLevel) {
+		t.Error("Expected event over burst to be dropped")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !s.Sample(InfoLevel) {
+		t.Error("Expected burst to reset after period")
+	}
+}
+
+func TestLevelSampler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(&buf).Level(DebugLevel).Sample(&LevelSampler{
+		DebugSampler: &RandomSampler{Probability: 0},
+	})
+
+	if logger.Debug() != nil {
+		t.Error("Expected sampled-out event to be nil")
+	}
+	logger.Debug().Msg("dropped")
+	logger.Error().Msg("kept")
+	logger.Print("stdlib is never sampled")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Errorf("Expected 2 log lines, got %d", len(lines))
+	}
+}
+
+func TestSampledCount(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(&buf).Sample(&EverySampler{N: 3}, WithSampledCount())
+
+	for i := 0; i < 6; i++ {
+		logger.Info().Msg("counted")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d", len(lines))
+	}
+	first, _ := parseLogLine(lines[0])
+	second, _ := parseLogLine(lines[1])
+	if first["sampled"] != float64(0) {
+		t.Errorf("Expected sampled=0 on first event, got %v", first["sampled"])
+	}
+	if second["sampled"] != float64(2) {
+		t.Errorf("Expected sampled=2 on second event, got %v", second["sampled"])
+	}
+}
+
+func BenchmarkSampledOutLogging(b *testing.B) {
+	logger := New().Output(&bytes.Buffer{}).Sample(&RandomSampler{Probability: 0})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info().Str("key", "value").Msg("dropped")
+	}
+}
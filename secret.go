@@ -0,0 +1,35 @@
+package xmuslogger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Secret wraps a sensitive string so it never prints its contents, whether
+// it is logged as a field, marshaled to JSON or formatted with fmt.
+type Secret string
+
+func (s Secret) String() string   { return redactedValue }
+func (s Secret) GoString() string { return redactedValue }
+
+// Format implements fmt.Formatter so every verb, including %#v and %x,
+// prints the placeholder.
+func (s Secret) Format(f fmt.State, verb rune) {
+	f.Write([]byte(redactedValue))
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redactedValue + `"`), nil
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redactedValue), nil
+}
+
+// Fingerprint returns a stable salted hash of the value that can be used to
+// correlate records without revealing the secret.
+func (s Secret) Fingerprint(salt string) string {
+	sum := sha256.Sum256([]byte(salt + string(s)))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,91 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecretFormatting(t *testing.T) {
+	s := Secret("hunter2")
+
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%q", "%x", "%10s"} {
+		if got := fmt.Sprintf(format, s); strings.Contains(got, "hunter2") || strings.Contains(got, "68756e74657232") {
+			t.Errorf("fmt.Sprintf(%q) leaked the secret: %s", format, got)
+		}
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"password": s})
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if string(b) != `{"password":"***"}` {
+		t.Errorf("Unexpected JSON: %s", b)
+	}
+}
+
+func TestSecretFingerprint(t *testing.T) {
+	s := Secret("hunter2")
+	if s.Fingerprint("salt") != s.Fingerprint("salt") {
+		t.Error("Fingerprint should be stable")
+	}
+	if s.Fingerprint("salt") == s.Fingerprint("pepper") {
+		t.Error("Fingerprint should depend on the salt")
+	}
+	if strings.Contains(s.Fingerprint("salt"), "hunter2") {
+		t.Error("Fingerprint leaked the secret")
+	}
+}
+
+func TestEventSecret(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(&buf)
+	logger.SetFlags(0)
+
+	logger.Info().Secret("password", "hunter2").Msg("login")
+	logger.Info().Interface("creds", struct {
+		User     string
+		Password Secret
+	}{"john", "hunter2"}).Msg("nested")
+	logger.Printf("password is %v", Secret("hunter2"))
+
+	output := buf.String()
+	if strings.Contains(output, "hunter2") {
+		t.Errorf("Output leaked the secret: %s", output)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	logEntry, err := parseLogLine(lines[0])
+	if err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	if logEntry["password"] != "***" {
+		t.Errorf("Expected password=***, got %v", logEntry["password"])
+	}
+
+	nested, err := parseLogLine(lines[1])
+	if err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	creds, ok := nested["creds"].(map[string]interface{})
+	if !ok || creds["User"] != "john" || creds["Password"] != "***" {
+		t.Errorf("Unexpected creds field: %v", nested["creds"])
+	}
+}
+
+func TestEventSecretFingerprint(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New().Output(&buf).Redact(NewRedactor(RedactWithMode(RedactHash), RedactSalt("salt")))
+
+	logger.Info().Secret("session", "abc").Msg("fingerprinted")
+
+	logEntry, err := parseLogLine(strings.TrimSpace(buf.String()))
+	if err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	if logEntry["session"] != Secret("abc").Fingerprint("salt") {
+		t.Errorf("Expected fingerprint, got %v", logEntry["session"])
+	}
+}
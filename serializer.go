@@ -60,10 +60,16 @@ func appendEscapedString(dst []byte, s string) []byte {
 }
 
 func appendBytes(dst []byte, val []byte) []byte {
+	return append(dst, val...)
+}
+
+// appendRaw writes val as is; it must already be valid JSON.
+func appendRaw(dst []byte, key string, val []byte) []byte {
+	dst = append(dst, '"')
+	dst = append(dst, key...)
+	dst = append(dst, '"', ':')
 	dst = append(dst, val...)
-	if len(dst) > 0 && dst[len(dst)-1] != ',' {
-		dst = append(dst)
-	}
+	dst = append(dst, ',')
 	return dst
 }
 
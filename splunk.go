@@ -0,0 +1,223 @@
+package xmuslogger
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SplunkRemoteWriter is a RemoteWriter that sends records to a Splunk HTTP
+// Event Collector. Each record becomes the event of a HEC envelope, and
+// envelopes are batched by concatenation. Records must be JSON encoded.
+type SplunkRemoteWriter struct {
+	http        *HTTPRemoteWriter
+	url         string // Base URL, without /services/collector
+	host        string
+	source      string
+	sourceType  string
+	index       string
+	channel     string
+	ackTimeout  time.Duration
+	httpOptions []HTTPOption
+	batch       *batcher[[]byte]
+}
+
+type SplunkOption func(*SplunkRemoteWriter)
+
+// NewSplunkRemoteWriter sends to the collector at endpoint, e.g.
+// https://splunk.example.com:8088, authenticating with the HEC token.
+// Batching is configured with SplunkHTTP(WithHTTPBatch(...)).
+func NewSplunkRemoteWriter(endpoint, token string, options ...SplunkOption) *SplunkRemoteWriter {
+	hostname, _ := os.Hostname()
+	w := &SplunkRemoteWriter{
+		url:        strings.TrimSuffix(endpoint, "/"),
+		host:       hostname,
+		sourceType: "_json",
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+	if i := strings.Index(w.url, "/services/collector"); i >= 0 {
+		w.url = w.url[:i]
+	}
+
+	w.http = NewHTTPRemoteWriter(endpoint, w.httpOptions...)
+	w.http.headers["Authorization"] = "Splunk " + token
+	if w.channel != "" {
+		w.http.headers["X-Splunk-Request-Channel"] = w.channel
+	}
+	w.batch = newBatcher(w.http.batchSize, w.http.flushInterval, w.send)
+	return w
+}
+
+// SplunkHost sets the envelope host. It defaults to the machine's hostname.
+func SplunkHost(host string) SplunkOption {
+	return func(w *SplunkRemoteWriter) {
+		w.host = host
+	}
+}
+
+func SplunkSource(source string) SplunkOption {
+	return func(w *SplunkRemoteWriter) {
+		w.source = source
+	}
+}
+
+// SplunkSourceType sets the envelope sourcetype, "_json" by default.
+func SplunkSourceType(sourceType string) SplunkOption {
+	return func(w *SplunkRemoteWriter) {
+		w.sourceType = sourceType
+	}
+}
+
+// SplunkIndex sets the target index. The token's default index is used
+// when it is empty.
+func SplunkIndex(index string) SplunkOption {
+	return func(w *SplunkRemoteWriter) {
+		w.index = index
+	}
+}
+
+// SplunkAck enables indexer acknowledgment on channel, a GUID; an empty
+// channel generates one. After each batch the writer polls /ack until the
+// batch is indexed, and reports an error if that takes longer than timeout.
+func SplunkAck(channel string, timeout time.Duration) SplunkOption {
+	return func(w *SplunkRemoteWriter) {
+		if channel == "" {
+			channel = newSplunkChannel()
+		}
+		w.channel = channel
+		w.ackTimeout = timeout
+	}
+}
+
+// SplunkHTTP configures the HTTP transport, e.g. WithHTTPClient or
+// WithHTTPBatch.
+func SplunkHTTP(options ...HTTPOption) SplunkOption {
+	return func(w *SplunkRemoteWriter) {
+		w.httpOptions = append(w.httpOptions, options...)
+	}
+}
+
+func (w *SplunkRemoteWriter) Write(data []byte) error {
+	return w.write(data, false)
+}
+
+func (w *SplunkRemoteWriter) WriteAsync(data []byte) error {
+	return w.write(data, true)
+}
+
+func (w *SplunkRemoteWriter) Flush() error { return w.batch.flush() }
+func (w *SplunkRemoteWriter) Close() error { return w.batch.close() }
+
+func (w *SplunkRemoteWriter) write(data []byte, async bool) error {
+	data, err := jsonRecord(data)
+	if err != nil {
+		return err
+	}
+
+	t := recordTime(data[1 : len(data)-1])
+	env := []byte(`{"time":`)
+	env = strconv.AppendFloat(env, float64(t.UnixMilli())/1000, 'f', 3, 64)
+	for _, f := range [...]struct{ key, val string }{
+		{"host", w.host},
+		{"source", w.source},
+		{"sourcetype", w.sourceType},
+		{"index", w.index},
+	} {
+		if f.val != "" {
+			env = append(env, `,"`+f.key+`":`...)
+			env = appendQuoted(env, f.val)
+		}
+	}
+	env = append(env, `,"event":`...)
+	env = append(env, data...)
+	env = append(env, '}')
+	return w.batch.add(env, async)
+}
+
+type splunkResponse struct {
+	Text  string `json:"text"`
+	Code  int    `json:"code"`
+	AckID *int64 `json:"ackId"`
+}
+
+func (w *SplunkRemoteWriter) send(batch [][]byte) error {
+	var body []byte
+	for _, env := range batch {
+		body = append(body, env...)
+	}
+
+	resp, err := w.http.post(w.url+"/services/collector/event", "application/json", body)
+	if err != nil {
+		return err
+	}
+	defer drainBody(resp)
+
+	var result splunkResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode/100 != 2 {
+		if result.Text != "" {
+			return fmt.Errorf("xmuslogger: Splunk HEC request failed: %s: %s", resp.Status, result.Text)
+		}
+		return fmt.Errorf("xmuslogger: Splunk HEC request failed: %s", resp.Status)
+	}
+	if w.channel == "" {
+		return nil
+	}
+	if result.AckID == nil {
+		return errors.New("xmuslogger: Splunk HEC response has no ackId; enable indexer acknowledgment for the token")
+	}
+	return w.waitAck(*result.AckID)
+}
+
+// waitAck polls /ack until ackID is acknowledged, backing off between polls.
+func (w *SplunkRemoteWriter) waitAck(ackID int64) error {
+	url := w.url + "/services/collector/ack?channel=" + w.channel
+	body := []byte(`{"acks":[` + strconv.FormatInt(ackID, 10) + `]}`)
+	deadline := time.Now().Add(w.ackTimeout)
+	wait := 50 * time.Millisecond
+
+	for {
+		resp, err := w.http.post(url, "application/json", body)
+		if err != nil {
+			return err
+		}
+		var result struct {
+			Acks map[string]bool `json:"acks"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		drainBody(resp)
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("xmuslogger: Splunk HEC ack request failed: %s", resp.Status)
+		}
+		if err != nil {
+			return fmt.Errorf("xmuslogger: invalid Splunk HEC ack response: %w", err)
+		}
+		if result.Acks[strconv.FormatInt(ackID, 10)] {
+			return nil
+		}
+
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("xmuslogger: Splunk HEC ack %d not received within %s", ackID, w.ackTimeout)
+		}
+		time.Sleep(wait)
+		if wait *= 2; wait > time.Second {
+			wait = time.Second
+		}
+	}
+}
+
+// newSplunkChannel returns a random version 4 UUID.
+func newSplunkChannel() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,154 @@
+package xmuslogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type hecServer struct {
+	mu        sync.Mutex
+	envelopes []map[string]interface{}
+	requests  int
+	polls     int
+	channels  []string
+	auth      string
+	ackAfter  int // Polls answered false before the ack
+}
+
+func newHECServer(t *testing.T) (*hecServer, *httptest.Server) {
+	s := &hecServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.auth = r.Header.Get("Authorization")
+		s.channels = append(s.channels, r.Header.Get("X-Splunk-Request-Channel"))
+
+		switch r.URL.Path {
+		case "/services/collector/event":
+			dec := json.NewDecoder(r.Body)
+			for {
+				var env map[string]interface{}
+				if err := dec.Decode(&env); err == io.EOF {
+					break
+				} else if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, `{"text":"Invalid data format","code":6}`)
+					return
+				}
+				s.envelopes = append(s.envelopes, env)
+			}
+			fmt.Fprintf(w, `{"text":"Success","code":0,"ackId":%d}`, s.requests)
+			s.requests++
+		case "/services/collector/ack":
+			if r.URL.Query().Get("channel") == "" {
+				t.Error("Expected channel query parameter")
+			}
+			var req struct {
+				Acks []int `json:"acks"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			s.polls++
+			fmt.Fprintf(w, `{"acks":{"%d":%t}}`, req.Acks[0], s.polls > s.ackAfter)
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func TestSplunkRemoteWriter(t *testing.T) {
+	server, srv := newHECServer(t)
+	w := NewSplunkRemoteWriter(srv.URL+"/services/collector", "secret-token",
+		SplunkHost("web-1"),
+		SplunkSource("shop"),
+		SplunkIndex("main"),
+		SplunkHTTP(WithHTTPBatch(10, time.Hour)),
+	)
+	defer w.Close()
+	logger := NewWithOutput(io.Discard).Remote(w)
+
+	logger.Info().Str("user", "john").Msg("login")
+	logger.Error().Msg("failed")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+
+	if server.requests != 1 || len(server.envelopes) != 2 {
+		t.Fatalf("Expected 2 envelopes in 1 request, got %d in %d", len(server.envelopes), server.requests)
+	}
+	if server.auth != "Splunk secret-token" {
+		t.Errorf("Expected Splunk authorization, got %q", server.auth)
+	}
+	env := server.envelopes[0]
+	if env["host"] != "web-1" || env["source"] != "shop" || env["sourcetype"] != "_json" || env["index"] != "main" {
+		t.Errorf("Unexpected envelope metadata %v", env)
+	}
+	if ts, ok := env["time"].(float64); !ok || time.Since(time.Unix(int64(ts), 0)) > time.Minute {
+		t.Errorf("Expected epoch time, got %v", env["time"])
+	}
+	event, ok := env["event"].(map[string]interface{})
+	if !ok || event["message"] != "login" || event["user"] != "john" {
+		t.Errorf("Unexpected event %v", env["event"])
+	}
+}
+
+func TestSplunkAck(t *testing.T) {
+	server, srv := newHECServer(t)
+	server.ackAfter = 2
+	w := NewSplunkRemoteWriter(srv.URL, "token", SplunkAck("", 5*time.Second))
+	defer w.Close()
+
+	if err := w.Write([]byte(`{"message":"indexed"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+	if server.polls != 3 {
+		t.Errorf("Expected 3 ack polls, got %d", server.polls)
+	}
+	channel := server.channels[0]
+	if len(channel) != 36 || strings.Count(channel, "-") != 4 {
+		t.Errorf("Expected a GUID channel, got %q", channel)
+	}
+	for _, c := range server.channels {
+		if c != channel {
+			t.Errorf("Expected every request on channel %q, got %q", channel, c)
+		}
+	}
+}
+
+func TestSplunkAckTimeout(t *testing.T) {
+	server, srv := newHECServer(t)
+	server.ackAfter = 1 << 30
+	w := NewSplunkRemoteWriter(srv.URL, "token", SplunkAck("0e8a1a5c-8d39-4f07-a0a3-4b3a8e5e6f70", 200*time.Millisecond))
+	defer w.Close()
+
+	w.Write([]byte(`{"message":"lost"}`))
+	err := w.Flush()
+	if err == nil || !strings.Contains(err.Error(), "not received") {
+		t.Errorf("Expected ack timeout error, got %v", err)
+	}
+}
+
+func TestSplunkErrors(t *testing.T) {
+	_, srv := newHECServer(t)
+	w := NewSplunkRemoteWriter(srv.URL, "token")
+	defer w.Close()
+
+	if err := w.Write([]byte("time=now")); err != errNotJSONRecord {
+		t.Errorf("Expected errNotJSONRecord, got %v", err)
+	}
+	w.batch.add([]byte("{broken"), false)
+	if err := w.Flush(); err == nil || !strings.Contains(err.Error(), "Invalid data format") {
+		t.Errorf("Expected HEC error text, got %v", err)
+	}
+}
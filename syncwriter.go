@@ -0,0 +1,60 @@
+package xmuslogger
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// syncWriter serializes writes so records from concurrent events never
+// interleave. Writes up to atomicLimit bytes skip the lock because the
+// kernel already writes them atomically.
+type syncWriter struct {
+	mu          sync.Mutex
+	w           io.Writer
+	atomicLimit int
+}
+
+// SyncWriter wraps w so that each Write is performed atomically. Writers
+// that are already safe for concurrent use, such as AsyncWriter and
+// RotatingFileWriter, are returned unchanged. For an *os.File opened with
+// O_APPEND or a pipe, records under PIPE_BUF are written without locking.
+func SyncWriter(w io.Writer) io.Writer {
+	switch w.(type) {
+	case *syncWriter, *AsyncWriter, *RotatingFileWriter:
+		return w
+	}
+
+	s := &syncWriter{w: w}
+	if f, ok := w.(*os.File); ok {
+		s.atomicLimit = atomicWriteLimit(f)
+	}
+	return s
+}
+
+func (s *syncWriter) Write(p []byte) (n int, err error) {
+	if len(p) <= s.atomicLimit {
+		return s.w.Write(p)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+func (s *syncWriter) WriteLevel(level Level, p []byte) (n int, err error) {
+	if len(p) <= s.atomicLimit {
+		return writeLevel(s.w, level, p)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeLevel(s.w, level, p)
+}
+
+func (s *syncWriter) Flush() error {
+	if f, ok := s.w.(flusher); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return f.Flush()
+	}
+	return nil
+}
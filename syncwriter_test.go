@@ -0,0 +1,108 @@
+package xmuslogger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSynchronizedLogger(t *testing.T) {
+	var buf bytes.Buffer // Deliberately not thread-safe
+	logger := New().Output(&buf).Synchronized()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				logger.Info().Int("goroutine", g).Int("i", i).Msg("synchronized")
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("Expected 1000 log lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if _, err := parseLogLine(line); err != nil {
+			t.Fatalf("Interleaved line %d: %q", i, line)
+		}
+	}
+}
+
+func TestSynchronizedWrapsNewOutputs(t *testing.T) {
+	var first, second bytes.Buffer
+	logger := New().Synchronized()
+	logger.SetOutput(&first)
+	logger.AddOutput(&second, OutputLevel(WarnLevel))
+
+	if _, ok := logger.writers[0].(*syncWriter); !ok {
+		t.Error("Expected SetOutput to wrap the writer")
+	}
+	outputs := logger.Outputs()
+	if len(outputs) != 2 || outputs[0] != &first || outputs[1] != &second {
+		t.Errorf("Expected Outputs() to return the original writers, got %v", outputs)
+	}
+
+	logger.RemoveOutput(&first)
+	if len(logger.Outputs()) != 1 {
+		t.Error("Expected RemoveOutput to find the wrapped writer")
+	}
+
+	// Outputs added before Synchronized are wrapped the other way round
+	var third bytes.Buffer
+	earlier := New()
+	earlier.AddOutput(&third, OutputLevel(WarnLevel))
+	synced := earlier.Synchronized()
+	if outputs := synced.Outputs(); len(outputs) != 2 || outputs[1] != &third {
+		t.Errorf("Expected Outputs() to unwrap both layers, got %v", outputs)
+	}
+	synced.RemoveOutput(&third)
+	if len(synced.Outputs()) != 1 {
+		t.Error("Expected RemoveOutput to find a writer wrapped by Synchronized")
+	}
+}
+
+func TestSyncWriterSkipsSafeWriters(t *testing.T) {
+	async := NewAsyncWriter(&bytes.Buffer{}, 16)
+	defer async.Close()
+
+	if SyncWriter(async) != async {
+		t.Error("AsyncWriter should not be wrapped")
+	}
+	wrapped := SyncWriter(&bytes.Buffer{})
+	if SyncWriter(wrapped) != wrapped {
+		t.Error("SyncWriter should not wrap twice")
+	}
+}
+
+func TestSyncWriterAppendFile(t *testing.T) {
+	dir := t.TempDir()
+
+	appendFile, err := os.OpenFile(filepath.Join(dir, "append.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer appendFile.Close()
+	plainFile, err := os.Create(filepath.Join(dir, "plain.log"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer plainFile.Close()
+
+	if runtime.GOOS == "linux" {
+		if limit := SyncWriter(appendFile).(*syncWriter).atomicLimit; limit != 4096 {
+			t.Errorf("Expected O_APPEND file to be atomic up to 4096 bytes, got %d", limit)
+		}
+	}
+	if limit := SyncWriter(plainFile).(*syncWriter).atomicLimit; limit != 0 {
+		t.Errorf("Expected plain file to always lock, got limit %d", limit)
+	}
+}
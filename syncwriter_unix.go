@@ -0,0 +1,37 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package xmuslogger
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// atomicWriteLimit returns the size up to which a single write to f cannot
+// interleave with other writers, or 0 if there is no such guarantee.
+func atomicWriteLimit(f *os.File) int {
+	pipeBuf := 512 // POSIX minimum
+	if runtime.GOOS == "linux" {
+		pipeBuf = 4096
+	}
+
+	if info, err := f.Stat(); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return pipeBuf
+	}
+
+	// SyscallConn, unlike Fd, leaves the descriptor's blocking mode alone
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return 0
+	}
+	var flags uintptr
+	var errno syscall.Errno
+	conn.Control(func(fd uintptr) {
+		flags, _, errno = syscall.Syscall(syscall.SYS_FCNTL, fd, syscall.F_GETFL, 0)
+	})
+	if errno == 0 && int(flags)&syscall.O_APPEND != 0 {
+		return pipeBuf
+	}
+	return 0
+}
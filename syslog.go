@@ -0,0 +1,318 @@
+package xmuslogger
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+type SyslogFormat int8
+
+const (
+	SyslogRFC5424 SyslogFormat = iota
+	SyslogRFC3164
+)
+
+type SyslogFacility int
+
+const (
+	SyslogKern SyslogFacility = iota
+	SyslogUser
+	SyslogMail
+	SyslogDaemon
+	SyslogAuth
+	SyslogSyslog
+	SyslogLPR
+	SyslogNews
+	SyslogUUCP
+	SyslogCron
+	SyslogAuthPriv
+	SyslogFTP
+)
+
+const (
+	SyslogLocal0 SyslogFacility = iota + 16
+	SyslogLocal1
+	SyslogLocal2
+	SyslogLocal3
+	SyslogLocal4
+	SyslogLocal5
+	SyslogLocal6
+	SyslogLocal7
+)
+
+// SyslogRemoteWriter is a RemoteWriter that sends records to a syslog
+// server over UDP, TCP or TLS. By default the JSON record is the message
+// body; with SyslogStructuredData the fields go into RFC 5424
+// STRUCTURED-DATA instead. TCP and TLS use octet-counting framing
+// (RFC 6587). The connection is opened on first use and re-established
+// when a write fails.
+type SyslogRemoteWriter struct {
+	conn     *netConn
+	format   SyslogFormat
+	facility SyslogFacility
+	hostname string
+	appName  string
+	procID   string
+	sdID     string
+	batch    *batcher[[]byte]
+}
+
+type SyslogOption func(*SyslogRemoteWriter)
+
+// NewSyslogRemoteWriter sends to addr over network, "udp" or "tcp". Use
+// SyslogTLS for TLS over TCP.
+func NewSyslogRemoteWriter(network, addr string, options ...SyslogOption) *SyslogRemoteWriter {
+	hostname, _ := os.Hostname()
+	w := &SyslogRemoteWriter{
+		conn:     &netConn{network: network, addr: addr, timeout: 5 * time.Second},
+		format:   SyslogRFC5424,
+		facility: SyslogUser,
+		hostname: hostname,
+		appName:  filepath.Base(os.Args[0]),
+		procID:   strconv.Itoa(os.Getpid()),
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+	// One message per send, so async writes go out in the background in order
+	w.batch = newBatcher(1, 0, w.send)
+	return w
+}
+
+func SyslogWithFormat(format SyslogFormat) SyslogOption {
+	return func(w *SyslogRemoteWriter) {
+		w.format = format
+	}
+}
+
+func SyslogWithFacility(facility SyslogFacility) SyslogOption {
+	return func(w *SyslogRemoteWriter) {
+		w.facility = facility
+	}
+}
+
+func SyslogHostname(hostname string) SyslogOption {
+	return func(w *SyslogRemoteWriter) {
+		w.hostname = hostname
+	}
+}
+
+func SyslogAppName(name string) SyslogOption {
+	return func(w *SyslogRemoteWriter) {
+		w.appName = name
+	}
+}
+
+func SyslogProcID(id string) SyslogOption {
+	return func(w *SyslogRemoteWriter) {
+		w.procID = id
+	}
+}
+
+// SyslogStructuredData writes fields as RFC 5424 STRUCTURED-DATA under id,
+// e.g. "fields@32473", and only the message as the body. Records must be
+// JSON encoded.
+func SyslogStructuredData(id string) SyslogOption {
+	return func(w *SyslogRemoteWriter) {
+		w.sdID = id
+	}
+}
+
+// SyslogTLS connects over TCP with TLS.
+func SyslogTLS(config *tls.Config) SyslogOption {
+	return func(w *SyslogRemoteWriter) {
+		w.conn.tlsConfig = config
+	}
+}
+
+// SyslogTimeout bounds dialing and each write, 5 seconds by default.
+func SyslogTimeout(timeout time.Duration) SyslogOption {
+	return func(w *SyslogRemoteWriter) {
+		w.conn.timeout = timeout
+	}
+}
+
+func (w *SyslogRemoteWriter) Write(data []byte) error {
+	return w.batch.add(w.message(data, time.Now()), false)
+}
+
+func (w *SyslogRemoteWriter) WriteAsync(data []byte) error {
+	return w.batch.add(w.message(data, time.Now()), true)
+}
+
+func (w *SyslogRemoteWriter) Flush() error { return w.batch.flush() }
+
+func (w *SyslogRemoteWriter) Close() error {
+	err := w.batch.close()
+	if cerr := w.conn.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// message formats a syslog message without transport framing.
+func (w *SyslogRemoteWriter) message(data []byte, t time.Time) []byte {
+	// Records other than JSON, such as logfmt, are sent as they are
+	var fields []byte
+	if rec, err := jsonRecord(data); err == nil {
+		data, fields = rec, rec[1:len(rec)-1]
+	}
+	severity := 6 // Informational
+	if raw := fieldValue(fields, "level"); raw != nil {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			if level, err := ParseLevel(s); err == nil {
+				severity = syslogSeverity(level)
+			}
+		}
+	}
+
+	m := []byte{'<'}
+	m = strconv.AppendInt(m, int64(w.facility)*8+int64(severity), 10)
+	m = append(m, '>')
+
+	if w.format == SyslogRFC3164 {
+		m = t.AppendFormat(m, time.Stamp)
+		m = append(m, ' ')
+		m = append(m, syslogHeaderField(w.hostname, 255)...)
+		m = append(m, ' ')
+		m = append(m, syslogHeaderField(w.appName, 32)...)
+		if w.procID != "" {
+			m = append(m, '[')
+			m = append(m, syslogHeaderField(w.procID, 128)...)
+			m = append(m, ']')
+		}
+		m = append(m, ": "...)
+		return append(m, data...)
+	}
+
+	m = append(m, "1 "...)
+	m = t.AppendFormat(m, "2006-01-02T15:04:05.000000Z07:00")
+	for _, f := range [...]struct {
+		val string
+		max int
+	}{{w.hostname, 255}, {w.appName, 48}, {w.procID, 128}, {"", 32}} { // MSGID is unused
+		m = append(m, ' ')
+		m = append(m, syslogHeaderField(f.val, f.max)...)
+	}
+	m = append(m, ' ')
+
+	if w.sdID == "" || fields == nil {
+		m = append(m, "- "...)
+		return append(m, data...)
+	}
+	var params []byte
+	var body string
+	for i := 0; i < len(fields); {
+		k, v, next := nextField(fields, i)
+		if next <= i {
+			break
+		}
+		i = next
+
+		var s string
+		if json.Unmarshal(v, &s) != nil {
+			s = string(v) // Non-strings keep their JSON form
+		}
+		switch string(k) {
+		case "message":
+			body = s
+			continue
+		case "level", "time":
+			continue // Carried by PRI and TIMESTAMP
+		}
+		params = append(params, ' ')
+		params = append(params, syslogSDName(string(k), 32)...)
+		params = append(params, '=', '"')
+		params = appendSDValue(params, s)
+		params = append(params, '"')
+	}
+
+	if params == nil {
+		m = append(m, '-')
+	} else {
+		m = append(m, '[')
+		m = append(m, syslogSDName(w.sdID, 32)...)
+		m = append(m, params...)
+		m = append(m, ']')
+	}
+	m = append(m, ' ')
+	return append(m, body...)
+}
+
+func (w *SyslogRemoteWriter) send(batch [][]byte) error {
+	for _, msg := range batch {
+		if !w.conn.datagram() {
+			// Octet counting
+			frame := strconv.AppendInt(nil, int64(len(msg)), 10)
+			frame = append(frame, ' ')
+			msg = append(frame, msg...)
+		}
+		if err := w.conn.write(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func syslogSeverity(level Level) int {
+	switch level {
+	case TraceLevel, DebugLevel:
+		return 7 // Debug
+	case InfoLevel:
+		return 6 // Informational
+	case WarnLevel:
+		return 4 // Warning
+	case ErrorLevel:
+		return 3 // Error
+	}
+	return 2 // Critical
+}
+
+// syslogHeaderField returns "-" for empty values and keeps only printable
+// ASCII other than space, truncated to max.
+func syslogHeaderField(s string, max int) string {
+	if s == "" {
+		return "-"
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// syslogSDName replaces characters not allowed in SD-IDs and PARAM-NAMEs.
+func syslogSDName(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+// appendSDValue escapes '"', '\' and ']' in a PARAM-VALUE.
+func appendSDValue(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			dst = append(dst, '\\', c)
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
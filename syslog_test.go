@@ -0,0 +1,244 @@
+package xmuslogger
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readOctetCounted reads RFC 6587 octet-counted frames from conn into frames.
+func readOctetCounted(conn net.Conn, frames chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil {
+			return
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return
+		}
+		frames <- string(buf)
+	}
+}
+
+func serveSyslog(t *testing.T, l net.Listener) <-chan string {
+	t.Cleanup(func() { l.Close() })
+	frames := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go readOctetCounted(conn, frames)
+		}
+	}()
+	return frames
+}
+
+func receive(t *testing.T, frames <-chan string) string {
+	t.Helper()
+	select {
+	case f := <-frames:
+		return f
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for syslog message")
+		return ""
+	}
+}
+
+func testSyslogOptions(options ...SyslogOption) []SyslogOption {
+	return append([]SyslogOption{SyslogHostname("web-1"), SyslogAppName("shop"), SyslogProcID("42")}, options...)
+}
+
+func TestSyslogRFC5424UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w := NewSyslogRemoteWriter("udp", conn.LocalAddr().String(), testSyslogOptions(SyslogWithFacility(SyslogLocal0))...)
+	defer w.Close()
+	logger := NewWithOutput(io.Discard).Remote(w)
+	logger.Error().Str("user", "john").Msg("payment failed")
+
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() returned error: %v", err)
+	}
+
+	msg := string(buf[:n])
+	pattern := regexp.MustCompile(`^<131>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}\S+ web-1 shop 42 - - \{"user":"john","message":"payment failed",.*\}$`)
+	if !pattern.MatchString(msg) {
+		t.Errorf("Unexpected RFC 5424 message: %q", msg)
+	}
+}
+
+func TestSyslogStructuredDataTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := serveSyslog(t, l)
+
+	w := NewSyslogRemoteWriter("tcp", l.Addr().String(), testSyslogOptions(SyslogStructuredData("fields@32473"))...)
+	defer w.Close()
+	logger := NewWithOutput(io.Discard).Remote(w)
+	logger.Warn().Str("user", `jo"hn]`).Int("port", 8080).Msg("slow request")
+	logger.Info().Msg("no fields")
+
+	msg := receive(t, frames)
+	if !strings.HasPrefix(msg, "<12>1 ") {
+		t.Errorf("Expected user.warning priority, got %q", msg)
+	}
+	if !strings.HasSuffix(msg, ` web-1 shop 42 - [fields@32473 user="jo\"hn\]" port="8080"] slow request`) {
+		t.Errorf("Unexpected structured data message: %q", msg)
+	}
+	if msg := receive(t, frames); !strings.HasSuffix(msg, " web-1 shop 42 - - no fields") {
+		t.Errorf("Expected nil STRUCTURED-DATA, got %q", msg)
+	}
+}
+
+func TestSyslogRFC3164(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := serveSyslog(t, l)
+
+	w := NewSyslogRemoteWriter("tcp", l.Addr().String(), testSyslogOptions(SyslogWithFormat(SyslogRFC3164), SyslogWithFacility(SyslogDaemon))...)
+	defer w.Close()
+	if err := w.WriteAsync([]byte(`{"message":"started","level":"debug"}`)); err != nil {
+		t.Fatalf("WriteAsync() returned error: %v", err)
+	}
+	w.Flush()
+
+	msg := receive(t, frames)
+	pattern := regexp.MustCompile(`^<31>[A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d web-1 shop\[42\]: \{"message":"started","level":"debug"\}$`)
+	if !pattern.MatchString(msg) {
+		t.Errorf("Unexpected RFC 3164 message: %q", msg)
+	}
+}
+
+func TestSyslogTLS(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := serveSyslog(t, l)
+
+	w := NewSyslogRemoteWriter("tcp", l.Addr().String(), testSyslogOptions(SyslogTLS(&tls.Config{RootCAs: pool}))...)
+	defer w.Close()
+	if err := w.Write([]byte(`{"message":"secure","level":"info"}`)); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if msg := receive(t, frames); !strings.HasSuffix(msg, `{"message":"secure","level":"info"}`) {
+		t.Errorf("Unexpected TLS message: %q", msg)
+	}
+}
+
+func TestSyslogReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	frames := make(chan string, 64)
+	go func() {
+		// Drop the first connection after one message
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		conn.Read(make([]byte, 1024))
+		conn.Close()
+
+		conn, err = l.Accept()
+		if err != nil {
+			return
+		}
+		readOctetCounted(conn, frames)
+	}()
+
+	w := NewSyslogRemoteWriter("tcp", l.Addr().String(), testSyslogOptions()...)
+	defer w.Close()
+	w.Write([]byte(`{"message":"first"}`))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		w.Write([]byte(`{"message":"again"}`))
+		select {
+		case msg := <-frames:
+			if !strings.HasSuffix(msg, `{"message":"again"}`) {
+				t.Errorf("Unexpected message after reconnect: %q", msg)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	t.Fatal("Writer did not reconnect")
+}
+
+func TestSyslogDialError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	w := NewSyslogRemoteWriter("tcp", addr, SyslogTimeout(time.Second))
+	defer w.Close()
+	if err := w.Write([]byte(`{"message":"lost"}`)); err == nil {
+		t.Error("Expected error when the server is down")
+	}
+}
+
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
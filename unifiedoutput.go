@@ -2,7 +2,6 @@ package xmuslogger
 
 import (
 	"strings"
-	"time"
 )
 
 type loggerWriter struct {
@@ -22,42 +21,10 @@ func (lw *loggerWriter) Write(p []byte) (n int, err error) {
 		message = message[:len(message)-1] // Remove newline
 	}
 
-	// Build JSON
-	var buf []byte
-	if lw.parent.context != nil {
-		buf = appendBytes(buf, lw.parent.context)
-	}
-	buf = appendString(buf, "message", message)
-	buf = appendTime(buf, "time", time.Now())
-	buf = appendString(buf, "level", "info")
-	buf = appendString(buf, "source", "stdlib")
-
-	jsonBuf := wrapJSON(buf)
-
-	// Write to all outputs
-	for _, w := range lw.parent.writers {
-		_, err := w.Write(append(jsonBuf, '\n'))
-		if err != nil {
-			// TODO : handle error
-			Panic(err)
-		}
-	}
-
-	// Write to remote
-	if lw.parent.remoteWriter != nil {
-		if lw.parent.async {
-			if err := lw.parent.remoteWriter.WriteAsync(jsonBuf); err != nil {
-				// TODO : handle error
-				Panic(err)
-			}
-		} else {
-			if err := lw.parent.remoteWriter.Write(jsonBuf); err != nil {
-				// TODO : handle error
-				Panic(err)
-
-			}
-		}
-	}
+	// Route through the event pipeline so hooks apply to stdlib output too
+	e := lw.parent.event(InfoLevel)
+	e.stdlib = true
+	e.Msg(message)
 
 	return len(p), nil
 }